@@ -0,0 +1,215 @@
+// Command parquettool works on parquet files written by
+// github.com/houkx/parquet-go.
+//
+// Usage:
+//
+//	parquettool merge -o out.parquet in1.parquet in2.parquet ...
+//	parquettool compact -o out.parquet [-rows n] [-sort col,-col] [-codec c] in1.parquet ...
+//	parquettool check old new
+//
+// The schemas given to check are avro schemas, or parquet files whose
+// footers hold them.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	park "github.com/houkx/parquet-go/parquet"
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"merge":   {"merge -o out.parquet in.parquet...  concatenate files without re-encoding them", merge},
+	"compact": {"compact -o out.parquet [-rows n] [-sort col,-col] [-codec c] in.parquet...  rewrite files into large row groups", compact},
+	"check":   {"check old new  report the changes of the schema new that break the readers of old", check},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "parquettool %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: parquettool <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	os.Exit(2)
+}
+
+func merge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	out := fs.String("o", "", "output file")
+	fs.Parse(args)
+	if *out == "" || fs.NArg() == 0 {
+		return fmt.Errorf("an output file (-o) and at least one input file are required")
+	}
+
+	inputs, closeAll, err := openAll(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	return create(*out, func(w io.Writer) error {
+		return park.Merge(w, inputs...)
+	})
+}
+
+func compact(args []string) error {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	out := fs.String("o", "", "output file")
+	rows := fs.Int("rows", 1000000, "rows per row group")
+	sortBy := fs.String("sort", "", "comma separated columns to sort by, prefix a column with - to sort descending")
+	codec := fs.String("codec", "", "compression codec of the output: snappy, gzip or uncompressed")
+	fs.Parse(args)
+	if *out == "" || fs.NArg() == 0 {
+		return fmt.Errorf("an output file (-o) and at least one input file are required")
+	}
+
+	var opts []func(*park.Compaction)
+	if *sortBy != "" {
+		var cols []park.SortColumn
+		for _, name := range strings.Split(*sortBy, ",") {
+			col := park.SortColumn{Name: strings.TrimPrefix(name, "-"), Descending: strings.HasPrefix(name, "-")}
+			cols = append(cols, col)
+		}
+		opts = append(opts, park.CompactionSortBy(cols...))
+	}
+	if *codec != "" {
+		c, err := sh.CompressionCodecFromString(strings.ToUpper(*codec))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, park.CompactionCodec(c))
+	}
+
+	inputs, closeAll, err := openAll(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	return create(*out, func(w io.Writer) error {
+		return park.Compact(nopCloser{w}, inputs, *rows, opts...)
+	})
+}
+
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("the old and the new schema are required")
+	}
+	before, err := schemaElements(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := schemaElements(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	changes := park.CheckSchemaElements(before, after)
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("%d incompatible changes", len(changes))
+	}
+	return nil
+}
+
+// schemaElements returns the schema of a parquet file, or of the files
+// written with the avro schema in the file.
+func schemaElements(name string) ([]*sh.SchemaElement, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("PAR1")) {
+		footer, err := park.ReadMetaData(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		return footer.Schema, nil
+	}
+	sc, err := park.NewSchema(string(data), sh.CompressionCodec_SNAPPY)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	if sc == nil {
+		return nil, fmt.Errorf("%s: not an avro schema of a record", name)
+	}
+	return sc.SchemaElements(), nil
+}
+
+// nopCloser lets create close the file itself.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// openAll opens the files in names for reading.
+func openAll(names []string) ([]io.ReadSeeker, func(), error) {
+	var files []*os.File
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	out := make([]io.ReadSeeker, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		out = append(out, f)
+	}
+	return out, closeAll, nil
+}
+
+// create writes a file with fn and removes it again if fn fails.
+func create(name string, fn func(w io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = fn(f)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(name)
+	}
+	return err
+}
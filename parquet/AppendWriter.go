@@ -0,0 +1,84 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// AppendFile is a parquet file that can be appended to, *os.File
+// implements it.
+type AppendFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Truncate(size int64) error
+}
+
+// NewAppendWriter opens a ParquetWriter that adds row groups to an existing
+// file written with the same schema.  The footer of the file is truncated
+// right away and rewritten by Close with both the old and the new row
+// groups, so the file is not readable until the writer is closed.
+func NewAppendWriter(schema *Schema, file AppendFile, pageSize int, opts ...func(*ParquetWriter)) (*ParquetWriter, error) {
+	footer, err := ReadMetaData(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSchema(schema, footer); err != nil {
+		return nil, err
+	}
+
+	size, err := getMetaDataSize(file)
+	if err != nil {
+		return nil, err
+	}
+	end, err := file.Seek(-4, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, PARK_FLAG) {
+		return nil, fmt.Errorf("not a parquet file, it ends with %q", magic)
+	}
+	offset := end - 4 - int64(size)
+	if err := file.Truncate(offset); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	meta := New(schema.PFields...)
+	meta.Resume(footer, offset)
+	return newParquetWriter(schema, meta, file, pageSize, opts...), nil
+}
+
+// checkSchema makes sure the columns in footer are the ones of schema.
+func checkSchema(schema *Schema, footer *sh.FileMetaData) error {
+	var leaves []*sh.SchemaElement
+	for _, se := range footer.Schema[1:] {
+		if se.Type != nil {
+			leaves = append(leaves, se)
+		}
+	}
+	if len(leaves) != len(schema.PFields) {
+		return fmt.Errorf("file has %d columns, schema has %d", len(leaves), len(schema.PFields))
+	}
+	for i, f := range schema.PFields {
+		var se sh.SchemaElement
+		f.Type(&se)
+		f.RepetitionType(&se)
+		l := leaves[i]
+		if l.Name != f.Name || *l.Type != *se.Type ||
+			l.GetRepetitionType() != se.GetRepetitionType() ||
+			l.GetTypeLength() != se.GetTypeLength() ||
+			annotation(l) != annotation(&se) {
+			return fmt.Errorf("column %d of the file is %s %s, schema has %s %s", i, l.Name, describeElement(l), f.Name, describeElement(&se))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// ColumnReader decodes the column chunks of one column,
+// a row group at a time.
+type ColumnReader struct {
+	field  *SchemaField
+	values Values
+	n      int    // number of values decoded
+	page   Values // non-null values of a page of an optional column
+	kept   Values // the values of the rows kept by keepRows
+	// dict holds the dictionary of the column chunk, if it has one.
+	dict    Values
+	dictLen int
+}
+
+func newColumnReader(field *SchemaField) *ColumnReader {
+	return &ColumnReader{field: field, values: *field.makeValues(0), page: *field.makeValues(0), kept: *field.makeValues(0)}
+}
+
+// readChunk decodes all pages of the column chunk ch.
+func (c *ColumnReader) readChunk(r io.ReadSeeker, ch *sh.ColumnChunk) error {
+	c.field.reset(&c.values)
+	c.n = 0
+	c.dictLen = 0
+	md := ch.MetaData
+	if _, err := r.Seek(chunkOffset(md), io.SeekStart); err != nil {
+		return err
+	}
+	for int64(c.n) < md.NumValues {
+		if err := c.readPage(r, md); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRanges decodes the rows of the column chunk ch that are in ranges,
+// the chunk of a row group of the given number of rows.  When the chunk
+// has an offset index only its pages that hold some of these rows are
+// read, otherwise all of them are and the other rows are dropped.
+func (c *ColumnReader) readRanges(r io.ReadSeeker, ch *sh.ColumnChunk, ranges []rowRange, rows int64) error {
+	oi := readOffsetIndex(r, ch)
+	if oi == nil || len(oi.PageLocations) == 0 {
+		if err := c.readChunk(r, ch); err != nil {
+			return err
+		}
+		c.keepRows([]rowRange{{0, int64(c.n)}}, ranges)
+		return nil
+	}
+	c.field.reset(&c.values)
+	c.n = 0
+	c.dictLen = 0
+	md := ch.MetaData
+	// the dictionary page comes before the data pages of the offset index.
+	if offset := chunkOffset(md); offset < oi.PageLocations[0].Offset {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		if err := c.readPage(r, md); err != nil {
+			return err
+		}
+	}
+	var pages []rowRange
+	for i, loc := range oi.PageLocations {
+		pr := pageRows(oi, i, rows)
+		if !overlaps(ranges, pr) {
+			continue
+		}
+		if _, err := r.Seek(loc.Offset, io.SeekStart); err != nil {
+			return err
+		}
+		n := c.n
+		if err := c.readPage(r, md); err != nil {
+			return err
+		}
+		if int64(c.n-n) != pr.to-pr.from {
+			return fmt.Errorf("column %s: page %d has %d values for %d rows", c.field.name, i, c.n-n, pr.to-pr.from)
+		}
+		pages = append(pages, pr)
+	}
+	c.keepRows(pages, ranges)
+	return nil
+}
+
+// readPage decodes the page at the current offset of r, a data page adds
+// its values to the values of the column.
+func (c *ColumnReader) readPage(r io.Reader, md *sh.ColumnMetaData) error {
+	ph, err := PageHeader(r)
+	if err != nil {
+		return err
+	}
+	data, err := pageData(r, ph, Page{Codec: md.Codec})
+	if err != nil {
+		return err
+	}
+	if ph.Type == sh.PageType_DICTIONARY_PAGE {
+		if err := c.readDictionary(ph, data); err != nil {
+			return fmt.Errorf("column %s: %s", c.field.name, err)
+		}
+		return nil
+	}
+	if ph.Type != sh.PageType_DATA_PAGE {
+		return fmt.Errorf("column %s: unsupported page type %s", c.field.name, ph.Type)
+	}
+	dph := ph.DataPageHeader
+	n := int(dph.NumValues)
+	if c.field.optional {
+		err = c.decodeOptional(dph.Encoding, data, n)
+	} else {
+		err = c.decode(dph.Encoding, data, n, &c.values)
+	}
+	if err != nil {
+		return fmt.Errorf("column %s: %s", c.field.name, err)
+	}
+	c.n += n
+	return nil
+}
+
+// keepRows only keeps the values of the rows in ranges, the values are
+// those of the rows of pages, in order.
+func (c *ColumnReader) keepRows(pages, ranges []rowRange) {
+	t := c.field.fieldType
+	c.field.reset(&c.kept)
+	var start, n int
+	for _, pg := range pages {
+		for _, rr := range ranges {
+			from, to := rr.from, rr.to
+			if from < pg.from {
+				from = pg.from
+			}
+			if to > pg.to {
+				to = pg.to
+			}
+			for row := from; row < to; row++ {
+				i := start + int(row-pg.from)
+				if c.values.defs != nil {
+					c.kept.defs = append(c.kept.defs, c.values.defs[i])
+				}
+				c.kept.appendFrom(t, &c.values, i)
+				n++
+			}
+		}
+		start += int(pg.to - pg.from)
+	}
+	c.values, c.kept = c.kept, c.values
+	c.n = n
+}
+
+func (c *ColumnReader) decode(enc sh.Encoding, data []byte, n int, values *Values) error {
+	if enc == sh.Encoding_RLE_DICTIONARY || enc == sh.Encoding_PLAIN_DICTIONARY {
+		return c.decodeDictionary(data, n, values)
+	}
+	return decode(c.field.fieldType, c.field.typeLength, enc, data, n, values)
+}
+
+// decodeOptional decodes the definition levels of a page of n values and
+// its non-null values, the nulls become zero values.
+func (c *ColumnReader) decodeOptional(enc sh.Encoding, data []byte, n int) error {
+	defs, l, err := readLevels(bytes.NewReader(data), 1)
+	if err != nil {
+		return err
+	}
+	if len(defs) < n {
+		return fmt.Errorf("page has %d definition levels for %d values", len(defs), n)
+	}
+	defs = defs[:n]
+	var nonNull int
+	for _, d := range defs {
+		if d != 0 {
+			nonNull++
+		}
+	}
+	c.field.reset(&c.page)
+	if err := c.decode(enc, data[l:], nonNull, &c.page); err != nil {
+		return err
+	}
+	var j int
+	for _, d := range defs {
+		c.values.defs = append(c.values.defs, d)
+		if d == 0 {
+			c.values.appendZero(c.field.fieldType)
+			continue
+		}
+		c.values.appendFrom(c.field.fieldType, &c.page, j)
+		j++
+	}
+	return nil
+}
+
+// decodePlain appends n PLAIN encoded values to values, FIXED_LEN_BYTE_ARRAY
+// values are length bytes long.
+func decodePlain(t sh.Type, length int, data []byte, n int, values *Values) error {
+	var size int
+	switch t {
+	case sh.Type_FIXED_LEN_BYTE_ARRAY:
+		size = length * n
+	case sh.Type_INT96:
+		length = 12
+		size = length * n
+	case sh.Type_INT32, sh.Type_FLOAT:
+		size = 4 * n
+	case sh.Type_INT64, sh.Type_DOUBLE:
+		size = 8 * n
+	case sh.Type_BOOLEAN:
+		size = (n + 7) / 8
+	}
+	if len(data) < size {
+		return io.ErrUnexpectedEOF
+	}
+
+	order := binary.LittleEndian
+	switch t {
+	case sh.Type_BYTE_ARRAY:
+		for i := 0; i < n; i++ {
+			if len(data) < 4 {
+				return io.ErrUnexpectedEOF
+			}
+			l := int(order.Uint32(data))
+			if len(data) < 4+l {
+				return io.ErrUnexpectedEOF
+			}
+			values.strs = append(values.strs, string(data[4:4+l]))
+			data = data[4+l:]
+		}
+	case sh.Type_INT32:
+		for i := 0; i < n; i++ {
+			values.i32s = append(values.i32s, int32(order.Uint32(data[4*i:])))
+		}
+	case sh.Type_INT64:
+		for i := 0; i < n; i++ {
+			values.i64s = append(values.i64s, int64(order.Uint64(data[8*i:])))
+		}
+	case sh.Type_FLOAT:
+		for i := 0; i < n; i++ {
+			values.f32s = append(values.f32s, math.Float32frombits(order.Uint32(data[4*i:])))
+		}
+	case sh.Type_DOUBLE:
+		for i := 0; i < n; i++ {
+			values.f64s = append(values.f64s, math.Float64frombits(order.Uint64(data[8*i:])))
+		}
+	case sh.Type_BOOLEAN:
+		for i := 0; i < n; i++ {
+			values.boos = append(values.boos, (data[i/8]>>uint(i%8))&1 == 1)
+		}
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		for i := 0; i < n; i++ {
+			values.bins = append(values.bins, data[length*i:length*(i+1):length*(i+1)])
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
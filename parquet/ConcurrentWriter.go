@@ -0,0 +1,138 @@
+package parquet
+
+import (
+	"github.com/json-iterator/go"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentWriter is a ParquetWriter that is safe to be called from many
+// goroutines.  Records are spread over a number of shards, each holding its
+// own RowGroupWriter, so producers only contend on the shard they write to.
+// A full shard is written to the file as a row group of its own, which is the
+// only time the file and the Metadata are locked.
+type ConcurrentWriter struct {
+	schema   *Schema
+	writer   io.WriteCloser
+	PageSize int
+	meta     *Metadata
+	shards   []*writerShard
+	next     uint32
+	rows     int64
+	spare    chan *RowGroupWriter // flushed row groups ready to be reused
+
+	mu  sync.Mutex // guards meta, writer and err
+	err error
+}
+
+type writerShard struct {
+	sync.Mutex
+	group *RowGroupWriter
+}
+
+// NewConcurrentWriter creates a ConcurrentWriter with the given number of
+// shards, usually the number of producer goroutines or GOMAXPROCS.
+func NewConcurrentWriter(schema *Schema, writer io.WriteCloser, pageSize int, shards int) *ConcurrentWriter {
+	if shards < 1 {
+		shards = 1
+	}
+	meta := New(schema.PFields...)
+	_, err := writer.Write(PARK_FLAG)
+	if err != nil {
+		return nil
+	}
+	p := &ConcurrentWriter{
+		writer:   writer,
+		schema:   schema,
+		PageSize: pageSize,
+		meta:     meta,
+		shards:   make([]*writerShard, shards),
+		spare:    make(chan *RowGroupWriter, shards),
+	}
+	for i := range p.shards {
+		p.shards[i] = &writerShard{group: NewRowGroupWriter(schema, meta, writer, pageSize)}
+	}
+	return p
+}
+
+func (p *ConcurrentWriter) WriteJson(json []byte) error {
+	record := p.schema.GetJsonMap()
+	jsoniter.Unmarshal(json, record)
+	err := p.Write(record)
+	p.schema.ReturnJsonMap(record)
+	return err
+}
+
+// write record
+func (p *ConcurrentWriter) Write(record *map[string]interface{}) error {
+	if err := p.schema.checkEnums(record); err != nil {
+		return err
+	}
+	shard := p.shards[atomic.AddUint32(&p.next, 1)%uint32(len(p.shards))]
+	shard.Lock()
+	group := shard.group
+	group.WriteRecord(record)
+	full := group.len == p.PageSize
+	if full {
+		shard.group = p.spareRowGroup()
+	}
+	shard.Unlock()
+	atomic.AddInt64(&p.rows, 1)
+	if !full {
+		return nil
+	}
+
+	err := p.flush(group)
+	select {
+	case p.spare <- group:
+	default:
+	}
+	return err
+}
+
+func (p *ConcurrentWriter) spareRowGroup() *RowGroupWriter {
+	select {
+	case group := <-p.spare:
+		return group
+	default:
+		return NewRowGroupWriter(p.schema, p.meta, p.writer, p.PageSize)
+	}
+}
+
+func (p *ConcurrentWriter) flush(group *RowGroupWriter) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		group.reset()
+		return p.err
+	}
+	p.err = group.Close()
+	return p.err
+}
+
+func (p *ConcurrentWriter) Rows() int64 {
+	return atomic.LoadInt64(&p.rows)
+}
+
+// Close flushes the rows left in every shard and writes the footer.
+// No Write may be running or be started once Close is called.
+func (p *ConcurrentWriter) Close() error {
+	for _, shard := range p.shards {
+		shard.Lock()
+		if shard.group.len > 0 {
+			p.flush(shard.group)
+		}
+		shard.Unlock()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.meta.Footer(p.writer); err != nil {
+		return err
+	}
+	_, err := p.writer.Write(PARK_FLAG)
+	return err
+}
@@ -0,0 +1,337 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// ParquetReader reads the records of a parquet file with flat, required
+// columns, such as the ones written by ParquetWriter.  Every row group is
+// decoded as a whole before its records are returned, but for the pages a
+// filter skips.
+type ParquetReader struct {
+	r         io.ReadSeeker
+	footer    *sh.FileMetaData
+	schema    *Schema
+	columns   []*ColumnReader
+	selected  []string
+	filter    Predicate
+	bound     filter
+	extra     []*ColumnReader        // filter columns that are not selected
+	readers   []*ColumnReader        // the selected and the extra columns
+	filtered  []*ColumnReader        // the columns of the filter
+	scratch   map[string]interface{} // filter columns of the current row
+	batchSize int
+	rowGroup  int // next row group to decode
+	end       int // row group after the last one to decode
+	rows      int // rows in the decoded row group
+	row       int // next row to return
+	// file is the schema of the file when the records are read with a
+	// reader schema, the columns then hold the fields of the reader schema
+	// and the sources are the columns of the file they are resolved from.
+	file      *Schema
+	resolved  []*resolvedColumn
+	sources   []*ColumnReader
+	statNames map[string]string // file columns of the filter statistics
+	// constants are the values of the fields the file does not have, the
+	// partition columns of the files of a dataset.
+	constants map[string]interface{}
+	binary    bool // unannotated BYTE_ARRAY columns are binary
+}
+
+// ParquetReaderColumns only reads the given columns, the records only
+// have these fields and the chunks of all other columns are never read.
+// It is an optional arg to NewParquetReader
+func ParquetReaderColumns(names ...string) func(*ParquetReader) {
+	return func(p *ParquetReader) { p.selected = names }
+}
+
+// ParquetReaderFilter only returns the records that match pred, the row
+// groups that cannot hold such records are never read, nor the pages of
+// the other ones when the file has page indexes.  The columns of pred do
+// not have to be selected by ParquetReaderColumns.
+// It is an optional arg to NewParquetReader
+func ParquetReaderFilter(pred Predicate) func(*ParquetReader) {
+	return func(p *ParquetReader) { p.filter = pred }
+}
+
+// ParquetReaderSchema reads the records with the fields of s rather than
+// the columns of the file, so that files written with former versions of
+// a schema can be read with the current one.  The fields are resolved as
+// in avro: a field the file does not have is null if it is optional, its
+// default value if it is required, and NewParquetReader returns an error
+// unless it has a default in the avro schema of s.  Columns that are not
+// fields of s are never read, and a field is read from the column with
+// one of its aliases when the file has no column with its name.  Integers are
+// promoted to wider integers, floats and doubles, floats to doubles, and
+// the nulls of a column read as a required field become its default.
+// Schema and the names of ParquetReaderColumns and the filter then refer
+// to s.
+// It is an optional arg to NewParquetReader
+func ParquetReaderSchema(s *Schema) func(*ParquetReader) {
+	return func(p *ParquetReader) { p.file = s }
+}
+
+// ParquetReaderBinary reads the BYTE_ARRAY columns without a converted
+// or logical type as binary columns of []byte values, such as the avro
+// bytes fields written by ParquetWriter.  Without it they are read as
+// strings, older writers do not annotate their string columns.
+// It is an optional arg to NewParquetReader
+func ParquetReaderBinary() func(*ParquetReader) {
+	return func(p *ParquetReader) { p.binary = true }
+}
+
+// NewParquetReader reads the footer of r and prepares to read its records.
+func NewParquetReader(r io.ReadSeeker, opts ...func(*ParquetReader)) (*ParquetReader, error) {
+	footer, err := ReadMetaData(r)
+	if err != nil {
+		return nil, err
+	}
+	return newParquetReader(r, footer, opts...)
+}
+
+// newParquetReader prepares to read the records of r whose footer has
+// already been read.
+func newParquetReader(r io.ReadSeeker, footer *sh.FileMetaData, opts ...func(*ParquetReader)) (*ParquetReader, error) {
+	p := &ParquetReader{
+		r:      r,
+		footer: footer,
+		end:    len(footer.RowGroups),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	schema, err := schemaFromFooter(footer, footerCodec(footer), p.binary)
+	if err != nil {
+		return nil, err
+	}
+	p.schema = schema
+	if p.file != nil {
+		p.file, p.schema = schema, p.file
+		schema = p.schema
+	}
+	if p.selected == nil {
+		for i := range schema.Fields {
+			p.columns = append(p.columns, newColumnReader(&schema.Fields[i]))
+		}
+	} else {
+		for _, name := range p.selected {
+			f := schema.Field(name)
+			if f == nil {
+				return nil, fmt.Errorf("column %s is not in the %s", name, p.schemaName())
+			}
+			p.columns = append(p.columns, newColumnReader(f))
+		}
+	}
+	if p.filter != nil {
+		if p.bound, err = p.filter.bind(schema); err != nil {
+			return nil, err
+		}
+		p.scratch = map[string]interface{}{}
+		p.bound.columns(func(name string) {
+			c := p.column(name)
+			if c == nil {
+				c = newColumnReader(schema.Field(name))
+				p.extra = append(p.extra, c)
+			}
+			for _, f := range p.filtered {
+				if f == c {
+					return
+				}
+			}
+			p.filtered = append(p.filtered, c)
+		})
+	}
+	p.readers = append(p.columns[:len(p.columns):len(p.columns)], p.extra...)
+	if p.file != nil {
+		if err := p.resolveSchema(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *ParquetReader) schemaName() string {
+	if p.file != nil {
+		return "schema"
+	}
+	return "file"
+}
+
+// column returns the reader of the named column among the selected and
+// the extra columns, nil if it is not read.
+func (p *ParquetReader) column(name string) *ColumnReader {
+	for _, c := range p.columns {
+		if c.field.name == name {
+			return c
+		}
+	}
+	for _, c := range p.extra {
+		if c.field.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Schema returns the schema of the file, using the codec of its first
+// column chunk, so the records can be written with a ParquetWriter.
+// It is the reader schema when one was given by ParquetReaderSchema.
+func (p *ParquetReader) Schema() *Schema {
+	return p.schema
+}
+
+// Columns returns the names of the columns of the records, in the order
+// of the columns of a Batch.
+func (p *ParquetReader) Columns() []string {
+	names := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		names[i] = c.field.name
+	}
+	return names
+}
+
+// FileMetaData returns the footer of the file.
+func (p *ParquetReader) FileMetaData() *sh.FileMetaData {
+	return p.footer
+}
+
+// Rows returns the number of rows in the file.
+func (p *ParquetReader) Rows() int64 {
+	return p.footer.NumRows
+}
+
+// Read sets the fields of the next record in record, it returns io.EOF
+// once all records have been read.
+func (p *ParquetReader) Read(record *map[string]interface{}) error {
+	for {
+		if err := p.nextRowGroup(); err != nil {
+			return err
+		}
+		if p.bound == nil || p.matches() {
+			break
+		}
+		p.row++
+	}
+	if *record == nil {
+		*record = make(map[string]interface{}, len(p.columns))
+	}
+	m := *record
+	for _, c := range p.columns {
+		m[c.field.name] = c.field.value(&c.values, p.row)
+	}
+	p.row++
+	return nil
+}
+
+// nextRowGroup decodes the next row group that may match the filter
+// once all rows of the current one have been read.
+func (p *ParquetReader) nextRowGroup() error {
+	for p.row == p.rows {
+		if p.rowGroup == p.end {
+			return io.EOF
+		}
+		rg := p.footer.RowGroups[p.rowGroup]
+		p.rowGroup++
+		var ranges []rowRange
+		if p.bound != nil {
+			g := p.rowGroupStats(rg)
+			if !p.bound.mayMatch(g) {
+				continue
+			}
+			ranges = p.bound.rowRanges(g)
+			if countRows(ranges) == rg.NumRows {
+				ranges = nil
+			} else if len(ranges) == 0 {
+				continue
+			}
+		}
+		if err := p.readRowGroup(rg, ranges); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches reports whether the current row matches the filter.
+func (p *ParquetReader) matches() bool {
+	for _, c := range p.filtered {
+		p.scratch[c.field.name] = c.field.value(&c.values, p.row)
+	}
+	return p.bound.match(p.scratch)
+}
+
+// decodedSize returns the uncompressed size of the column chunks of the
+// row group that are decoded, an estimate of the memory they take.
+func (p *ParquetReader) decodedSize(rg *sh.RowGroup) int64 {
+	decoded := p.readers
+	if p.file != nil {
+		decoded = p.sources
+	}
+	var size int64
+	for _, c := range decoded {
+		if ch := findColumnChunk(rg, c.field.name); ch != nil {
+			size += ch.MetaData.TotalUncompressedSize
+		}
+	}
+	return size
+}
+
+// rowGroupStats returns the statistics of the row group, with a reader
+// schema only those of the fields of the same type as their column.
+func (p *ParquetReader) rowGroupStats(rg *sh.RowGroup) *rowGroupStats {
+	s := newRowGroupStats(p.r, p.footer, rg)
+	s.names = p.statNames
+	return s
+}
+
+// readRowGroup decodes the rows of the row group in ranges, all of them
+// if ranges is nil.
+func (p *ParquetReader) readRowGroup(rg *sh.RowGroup, ranges []rowRange) error {
+	p.rows, p.row = 0, 0
+	decoded := p.readers
+	if p.file != nil {
+		decoded = p.sources
+	}
+	rows := rg.NumRows
+	if ranges != nil {
+		rows = countRows(ranges)
+	}
+	for _, c := range decoded {
+		ch := findColumnChunk(rg, c.field.name)
+		if ch == nil {
+			return fmt.Errorf("row group has no column %s", c.field.name)
+		}
+		var err error
+		if ranges == nil {
+			err = c.readChunk(p.r, ch)
+		} else {
+			err = c.readRanges(p.r, ch, ranges, rg.NumRows)
+		}
+		if err != nil {
+			return err
+		}
+		if int64(c.n) != rows {
+			return fmt.Errorf("column %s has %d values, the row group %d rows", c.field.name, c.n, rows)
+		}
+	}
+	p.rows = int(rows)
+	p.row = 0
+	for _, r := range p.resolved {
+		r.resolve(p.rows)
+	}
+	return nil
+}
+
+// findColumnChunk returns the chunk of the column with the given path.
+func findColumnChunk(rg *sh.RowGroup, name string) *sh.ColumnChunk {
+	for _, ch := range rg.Columns {
+		if strings.Join(ch.MetaData.PathInSchema, ".") == name {
+			return ch
+		}
+	}
+	return nil
+}
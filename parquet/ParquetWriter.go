@@ -1,8 +1,11 @@
 package parquet
 
 import (
+	"fmt"
+	sh "github.com/houkx/parquet-go/parquet/schema"
 	"github.com/json-iterator/go"
 	"io"
+	"sync"
 )
 
 type ParquetWriter struct {
@@ -13,56 +16,225 @@ type ParquetWriter struct {
 	meta            *Metadata
 	currentRowGroup *RowGroupWriter //当前的rowGroup,只保存一个,完成一个就写入一个,释放一个
 	rows            int64
+
+	// async mode: full row groups are flushed by a background goroutine
+	maxInFlight int
+	groups      int                  // number of RowGroupWriters allocated so far
+	pending     chan *RowGroupWriter // full row groups waiting to be flushed
+	idle        chan *RowGroupWriter // flushed row groups ready to be reused
+	wg          sync.WaitGroup
+	errMu       sync.Mutex
+	err         error
 }
 
 var PARK_FLAG = []byte("PAR1")
 
-func NewParquetWriter(schema *Schema, writer io.WriteCloser, pageSize int) *ParquetWriter {
+// ParquetWriterAsync makes Write hand full row groups off to a background
+// goroutine instead of compressing and writing them inline.  At most
+// maxInFlight row groups may be waiting to be flushed; Write blocks once
+// that limit is reached.  Errors from the background flush are returned by
+// the next call to Write or Close.
+// It is an optional arg to NewParquetWriter
+func ParquetWriterAsync(maxInFlight int) func(*ParquetWriter) {
+	return func(p *ParquetWriter) {
+		if maxInFlight < 1 {
+			maxInFlight = 1
+		}
+		p.maxInFlight = maxInFlight
+	}
+}
+
+// SortColumn is a column that rows are sorted by.
+type SortColumn struct {
+	Name       string
+	Descending bool
+}
+
+// ParquetWriterSortedBy records in the footer that the rows of every row
+// group are sorted by cols, which the caller has to make sure of.  Nulls
+// sort first in ascending and last in descending order, as Compact sorts
+// them.  Columns that are not in the schema are ignored.
+// It is an optional arg to NewParquetWriter
+func ParquetWriterSortedBy(cols ...SortColumn) func(*ParquetWriter) {
+	return func(p *ParquetWriter) {
+		var scs []*sh.SortingColumn
+		for _, col := range cols {
+			for i, f := range p.schema.Fields {
+				if f.name == col.Name {
+					scs = append(scs, &sh.SortingColumn{ColumnIdx: int32(i), Descending: col.Descending, NullsFirst: !col.Descending})
+				}
+			}
+		}
+		p.meta.SortingColumns(scs)
+	}
+}
+
+func NewParquetWriter(schema *Schema, writer io.WriteCloser, pageSize int, opts ...func(*ParquetWriter)) *ParquetWriter {
 	meta := New(schema.PFields...)
 	_, err := writer.Write(PARK_FLAG) //先写入parquet文件开头的标识
 	if err != nil {
 		return nil
 	}
-	return &ParquetWriter{
+	return newParquetWriter(schema, meta, writer, pageSize, opts...)
+}
+
+func newParquetWriter(schema *Schema, meta *Metadata, writer io.WriteCloser, pageSize int, opts ...func(*ParquetWriter)) *ParquetWriter {
+	p := &ParquetWriter{
 		writer:          writer,
 		schema:          schema,
 		PageSize:        pageSize,
 		meta:            meta,
 		currentRowGroup: NewRowGroupWriter(schema, meta, writer, pageSize),
+		groups:          1,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	if p.maxInFlight > 0 {
+		p.pending = make(chan *RowGroupWriter, p.maxInFlight)
+		p.idle = make(chan *RowGroupWriter, p.maxInFlight+1)
+		p.wg.Add(1)
+		go p.flushLoop()
+	}
+	return p
 }
-func (p *ParquetWriter) WriteJson(json []byte) {
+func (p *ParquetWriter) WriteJson(json []byte) error {
 	record := p.schema.GetJsonMap()
 	jsoniter.Unmarshal(json, record)
-	p.Write(record)
+	err := p.Write(record)
 	p.schema.ReturnJsonMap(record)
+	return err
 }
 
 // write record
 func (p *ParquetWriter) Write(record *map[string]interface{}) error {
+	if err := p.asyncErr(); err != nil {
+		return err
+	}
+	if err := p.schema.checkEnums(record); err != nil {
+		return err
+	}
 	group := p.currentRowGroup
 	group.WriteRecord(record)
 	p.rows++
-	var err error = nil
 	if group.len == p.PageSize {
-		err = p.currentRowGroup.Close()
-		//p.currentRowGroup = NewRowGroupWriter(p.schema, p.meta, p.writer, p.PageSize)
-		p.meta.StartRowGroup(p.schema.PFields...)
+		return p.flush()
 	}
-	return err
+	return nil
+}
+
+// WriteBatch writes the rows of batch, whose columns hold the values
+// of the schema fields with the same name.  The typed slices are copied
+// into the row group buffers as they are, without per row conversions.
+// Nulls marks the nulls of optional columns, columns missing from batch
+// get their default value, or null for optional columns.
+func (p *ParquetWriter) WriteBatch(batch *Batch) error {
+	if err := p.asyncErr(); err != nil {
+		return err
+	}
+	for i := range batch.Columns {
+		c := &batch.Columns[i]
+		f := p.schema.Field(c.Name)
+		if f == nil {
+			return fmt.Errorf("column %s is not in the schema", c.Name)
+		}
+		if err := c.check(f, batch.Rows); err != nil {
+			return err
+		}
+	}
+	for from := 0; from < batch.Rows; {
+		group := p.currentRowGroup
+		to := from + p.PageSize - group.len
+		if to > batch.Rows {
+			to = batch.Rows
+		}
+		group.WriteColumns(batch, from, to)
+		p.rows += int64(to - from)
+		from = to
+		if group.len == p.PageSize {
+			if err := p.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flush writes the current row group, or in async mode hands it off to
+// the background goroutine and switches to a fresh RowGroupWriter.
+func (p *ParquetWriter) flush() error {
+	if p.pending == nil {
+		return p.currentRowGroup.Close()
+	}
+	p.pending <- p.currentRowGroup
+	p.currentRowGroup = p.nextRowGroup()
+	return nil
+}
+
+// nextRowGroup returns an idle RowGroupWriter, allocating a new one while
+// fewer than maxInFlight+1 exist and blocking until one is flushed otherwise.
+func (p *ParquetWriter) nextRowGroup() *RowGroupWriter {
+	select {
+	case group := <-p.idle:
+		return group
+	default:
+	}
+	if p.groups <= p.maxInFlight {
+		p.groups++
+		return NewRowGroupWriter(p.schema, p.meta, p.writer, p.PageSize)
+	}
+	return <-p.idle
+}
+
+func (p *ParquetWriter) flushLoop() {
+	defer p.wg.Done()
+	for group := range p.pending {
+		if p.asyncErr() != nil {
+			// the file is already broken, just drop the rows
+			group.reset()
+		} else if err := group.Close(); err != nil {
+			p.errMu.Lock()
+			p.err = err
+			p.errMu.Unlock()
+		}
+		p.idle <- group
+	}
+}
+
+func (p *ParquetWriter) asyncErr() error {
+	if p.pending == nil {
+		return nil
+	}
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	return p.err
 }
 
 func (p *ParquetWriter) Rows() int64 {
 	return p.rows
 }
 
+// FileMetaData returns the footer written by Close, nil before that.
+func (p *ParquetWriter) FileMetaData() *sh.FileMetaData {
+	return p.meta.metadata
+}
+
 func (p *ParquetWriter) Close() error {
+	var err error
 	if p.currentRowGroup.len > 0 {
-		p.currentRowGroup.Close()
+		err = p.flush()
+	}
+	if p.pending != nil {
+		close(p.pending)
+		p.wg.Wait()
+		err = p.asyncErr()
+	}
+	if err != nil {
+		return err
 	}
 	if err := p.meta.Footer(p.writer); err != nil {
 		return err
 	}
-	_, err := p.writer.Write(PARK_FLAG)
+	_, err = p.writer.Write(PARK_FLAG)
 	return err
 }
@@ -0,0 +1,216 @@
+package parquet
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/json-iterator/go"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HiveDefaultPartition is the directory name used for empty or null
+// partition values.
+const HiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// PartitionedWriter writes records to a Hive style partitioned dataset, a
+// record with date=2020-04-29 and type=3 goes to
+// root/date=2020-04-29/type=3/part-N.parquet.  The partition columns are
+// not stored in the files.  At most MaxOpen files are kept open, the least
+// recently written one is finished when another partition needs a file.
+type PartitionedWriter struct {
+	schema     *Schema
+	fileSchema *Schema
+	columns    []*SchemaField
+	root       string
+	PageSize   int
+	MaxOpen    int
+	opts       []func(*ParquetWriter)
+
+	open  map[string]*list.Element
+	lru   *list.List // of *partitionFile, most recently written first
+	parts map[string]int
+	rows  int64
+}
+
+type partitionFile struct {
+	dir    string
+	file   *os.File
+	writer *ParquetWriter
+}
+
+// PartitionedWriterMaxOpen sets the number of files kept open, 16 by default.
+// It is an optional arg to NewPartitionedWriter
+func PartitionedWriterMaxOpen(n int) func(*PartitionedWriter) {
+	return func(p *PartitionedWriter) { p.MaxOpen = n }
+}
+
+// PartitionedWriterParquetOptions sets the options of every ParquetWriter.
+// It is an optional arg to NewPartitionedWriter
+func PartitionedWriterParquetOptions(opts ...func(*ParquetWriter)) func(*PartitionedWriter) {
+	return func(p *PartitionedWriter) { p.opts = opts }
+}
+
+// NewPartitionedWriter creates a PartitionedWriter that partitions by the
+// given columns of schema, in that order.
+func NewPartitionedWriter(schema *Schema, columns []string, root string, pageSize int, opts ...func(*PartitionedWriter)) (*PartitionedWriter, error) {
+	p := &PartitionedWriter{
+		schema:     schema,
+		fileSchema: schema.Without(columns...),
+		root:       root,
+		PageSize:   pageSize,
+		MaxOpen:    16,
+		open:       make(map[string]*list.Element),
+		lru:        list.New(),
+		parts:      make(map[string]int),
+	}
+	for _, col := range columns {
+		f := schema.Field(col)
+		if f == nil {
+			return nil, fmt.Errorf("partition column %s is not in the schema", col)
+		}
+		p.columns = append(p.columns, f)
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.MaxOpen < 1 {
+		p.MaxOpen = 1
+	}
+	return p, nil
+}
+
+func (p *PartitionedWriter) WriteJson(json []byte) error {
+	record := p.schema.GetJsonMap()
+	jsoniter.Unmarshal(json, record)
+	err := p.Write(record)
+	p.schema.ReturnJsonMap(record)
+	return err
+}
+
+// write record
+func (p *PartitionedWriter) Write(record *map[string]interface{}) error {
+	dir := p.partition(record)
+	e, ok := p.open[dir]
+	if ok {
+		p.lru.MoveToFront(e)
+	} else {
+		pf, err := p.create(dir)
+		if err != nil {
+			return err
+		}
+		e = p.lru.PushFront(pf)
+		p.open[dir] = e
+	}
+	if err := e.Value.(*partitionFile).writer.Write(record); err != nil {
+		return err
+	}
+	p.rows++
+	return nil
+}
+
+// partition returns the directory of the record relative to root.
+func (p *PartitionedWriter) partition(record *map[string]interface{}) string {
+	parts := make([]string, len(p.columns))
+	for i, f := range p.columns {
+		var s string
+		if v := f.Value(record); v != nil {
+			s = fmt.Sprint(v)
+		}
+		parts[i] = f.name + "=" + escapePartitionValue(s)
+	}
+	return filepath.Join(parts...)
+}
+
+func (p *PartitionedWriter) create(dir string) (*partitionFile, error) {
+	for p.lru.Len() >= p.MaxOpen {
+		if err := p.finish(p.lru.Back()); err != nil {
+			return nil, err
+		}
+	}
+	full := filepath.Join(p.root, dir)
+	if err := os.MkdirAll(full, 0755); err != nil {
+		return nil, err
+	}
+	var file *os.File
+	for file == nil {
+		n := p.parts[dir]
+		p.parts[dir] = n + 1
+		var err error
+		file, err = os.OpenFile(filepath.Join(full, fmt.Sprintf("part-%d.parquet", n)), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil && !os.IsExist(err) {
+			return nil, err
+		}
+	}
+	w := NewParquetWriter(p.fileSchema, file, p.PageSize, p.opts...)
+	if w == nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to start parquet file %s", file.Name())
+	}
+	return &partitionFile{dir: dir, file: file, writer: w}, nil
+}
+
+func (p *PartitionedWriter) finish(e *list.Element) error {
+	pf := p.lru.Remove(e).(*partitionFile)
+	delete(p.open, pf.dir)
+	err := pf.writer.Close()
+	if e := pf.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Rows returns the number of rows written to all partitions.
+func (p *PartitionedWriter) Rows() int64 {
+	return p.rows
+}
+
+// Close finishes all open files.
+func (p *PartitionedWriter) Close() error {
+	var err error
+	for p.lru.Len() > 0 {
+		if e := p.finish(p.lru.Front()); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// escapePartitionValue escapes a value the way Hive escapes partition
+// directory names.
+func escapePartitionValue(s string) string {
+	if s == "" {
+		return HiveDefaultPartition
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == 0x7F || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescapePartitionValue reverses escapePartitionValue, ok is false for
+// the default partition of empty and null values.
+func unescapePartitionValue(s string) (value string, ok bool) {
+	if s == HiveDefaultPartition {
+		return "", false
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), true
+}
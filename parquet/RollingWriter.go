@@ -0,0 +1,248 @@
+package parquet
+
+import (
+	"fmt"
+	"github.com/json-iterator/go"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// RollingFile describes a file finished by a RollingWriter.
+type RollingFile struct {
+	Path  string
+	Rows  int64
+	Bytes int64
+	// Min and Max hold the statistics of the columns passed to
+	// RollingWriterStats, columns without statistics are left out.
+	Min map[string]interface{}
+	Max map[string]interface{}
+}
+
+// RollingWriter writes a continuous stream of records to a sequence of
+// parquet files.  It rotates to a new file once the current one reaches
+// MaxBytes, MaxRows or has been open for MaxAge, whichever happens first.
+type RollingWriter struct {
+	schema   *Schema
+	PageSize int
+	MaxBytes int64
+	MaxRows  int64
+	MaxAge   time.Duration
+
+	create   func(index int) (string, io.WriteCloser, error)
+	finished func(RollingFile) error
+	stats    []string
+	opts     []func(*ParquetWriter)
+
+	index   int
+	path    string
+	file    *countingFile
+	current *ParquetWriter
+	opened  time.Time
+	rows    int64
+}
+
+// countingFile counts the bytes written to the file, buffered rows
+// are only counted once their row group is flushed.
+type countingFile struct {
+	io.WriteCloser
+	n int64
+}
+
+// Write may be called by the goroutine of ParquetWriterAsync while the
+// RollingWriter reads the count, so the count is updated atomically.
+func (c *countingFile) Write(p []byte) (int, error) {
+	n, err := c.WriteCloser.Write(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+// written returns the number of bytes written to the file.
+func (c *countingFile) written() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// RollingWriterMaxBytes rotates files once n bytes were written.  Rows are
+// written a row group at a time, so a file may exceed n by one row group.
+// It is an optional arg to NewRollingWriter
+func RollingWriterMaxBytes(n int64) func(*RollingWriter) {
+	return func(p *RollingWriter) { p.MaxBytes = n }
+}
+
+// RollingWriterMaxRows rotates files once n rows were written.
+// It is an optional arg to NewRollingWriter
+func RollingWriterMaxRows(n int64) func(*RollingWriter) {
+	return func(p *RollingWriter) { p.MaxRows = n }
+}
+
+// RollingWriterMaxAge rotates files that have been open for d.  The age is
+// checked on Write, call Rotate from a timer to close idle files as well.
+// It is an optional arg to NewRollingWriter
+func RollingWriterMaxAge(d time.Duration) func(*RollingWriter) {
+	return func(p *RollingWriter) { p.MaxAge = d }
+}
+
+// RollingWriterStats reports the min and max of the given columns
+// for every finished file.
+// It is an optional arg to NewRollingWriter
+func RollingWriterStats(columns ...string) func(*RollingWriter) {
+	return func(p *RollingWriter) { p.stats = columns }
+}
+
+// RollingWriterOnFinish sets the callback called for every finished file,
+// an error returned by it is returned by the Write or Close that rotated.
+// It is an optional arg to NewRollingWriter
+func RollingWriterOnFinish(fn func(RollingFile) error) func(*RollingWriter) {
+	return func(p *RollingWriter) { p.finished = fn }
+}
+
+// RollingWriterParquetOptions sets the options of every ParquetWriter.
+// It is an optional arg to NewRollingWriter
+func RollingWriterParquetOptions(opts ...func(*ParquetWriter)) func(*RollingWriter) {
+	return func(p *RollingWriter) { p.opts = opts }
+}
+
+// NewRollingWriter creates a RollingWriter, create is called with 0, 1, 2...
+// to open a new file and returns its path along with the file.  Files are
+// only created when the first record for them is written.
+func NewRollingWriter(schema *Schema, create func(index int) (string, io.WriteCloser, error), pageSize int, opts ...func(*RollingWriter)) *RollingWriter {
+	p := &RollingWriter{
+		schema:   schema,
+		PageSize: pageSize,
+		create:   create,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *RollingWriter) WriteJson(json []byte) error {
+	record := p.schema.GetJsonMap()
+	jsoniter.Unmarshal(json, record)
+	err := p.Write(record)
+	p.schema.ReturnJsonMap(record)
+	return err
+}
+
+// write record
+func (p *RollingWriter) Write(record *map[string]interface{}) error {
+	if p.current == nil {
+		if err := p.open(); err != nil {
+			return err
+		}
+	}
+	if err := p.current.Write(record); err != nil {
+		return err
+	}
+	p.rows++
+	if p.full() {
+		return p.Rotate()
+	}
+	return nil
+}
+
+func (p *RollingWriter) full() bool {
+	return (p.MaxRows > 0 && p.current.Rows() >= p.MaxRows) ||
+		(p.MaxBytes > 0 && p.file.written() >= p.MaxBytes) ||
+		(p.MaxAge > 0 && time.Since(p.opened) >= p.MaxAge)
+}
+
+func (p *RollingWriter) open() error {
+	path, w, err := p.create(p.index)
+	if err != nil {
+		return err
+	}
+	p.index++
+	p.path = path
+	p.file = &countingFile{WriteCloser: w}
+	p.current = NewParquetWriter(p.schema, p.file, p.PageSize, p.opts...)
+	if p.current == nil {
+		w.Close()
+		return fmt.Errorf("unable to start parquet file %s", path)
+	}
+	p.opened = time.Now()
+	return nil
+}
+
+// Rotate finishes the current file, if any.  The next Write starts a new one.
+func (p *RollingWriter) Rotate() error {
+	if p.current == nil {
+		return nil
+	}
+	pw, file := p.current, p.file
+	p.current, p.file = nil, nil
+	err := pw.Close()
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if p.finished == nil {
+		return nil
+	}
+	return p.finished(p.fileInfo(pw, file))
+}
+
+func (p *RollingWriter) fileInfo(pw *ParquetWriter, file *countingFile) RollingFile {
+	out := RollingFile{
+		Path:  p.path,
+		Rows:  pw.Rows(),
+		Bytes: file.written(),
+		Min:   map[string]interface{}{},
+		Max:   map[string]interface{}{},
+	}
+	fmd := pw.FileMetaData()
+	for _, col := range p.stats {
+		se := leafElement(fmd, col)
+		if se == nil {
+			continue
+		}
+		var min, max []byte
+		for _, rg := range fmd.RowGroups {
+			for _, ch := range rg.Columns {
+				sts := ch.MetaData.Statistics
+				if sts == nil || strings.Join(ch.MetaData.PathInSchema, ".") != col {
+					continue
+				}
+				if sts.MinValue != nil && (min == nil || compareStatValues(se, sts.MinValue, min) < 0) {
+					min = sts.MinValue
+				}
+				if sts.MaxValue != nil && (max == nil || compareStatValues(se, sts.MaxValue, max) > 0) {
+					max = sts.MaxValue
+				}
+			}
+		}
+		if min != nil {
+			out.Min[col] = StatValue(se, min)
+		}
+		if max != nil {
+			out.Max[col] = StatValue(se, max)
+		}
+	}
+	return out
+}
+
+// leafElement finds the SchemaElement of a top level column.
+func leafElement(fmd *sh.FileMetaData, name string) *sh.SchemaElement {
+	for _, se := range fmd.Schema[1:] {
+		if se.Name == name && se.Type != nil {
+			return se
+		}
+	}
+	return nil
+}
+
+// Rows returns the number of rows written to all files.
+func (p *RollingWriter) Rows() int64 {
+	return p.rows
+}
+
+// Close finishes the current file.
+func (p *RollingWriter) Close() error {
+	return p.Rotate()
+}
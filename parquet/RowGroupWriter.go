@@ -22,18 +22,50 @@ func NewRowGroupWriter(schema *Schema, meta *Metadata, w io.Writer, maxRecords i
 		w: w, schema: schema,
 		fieldData: fieldDatas}
 }
+
+// WriteRecord only buffers the record, the Metadata is not touched
+// until Close so that a full row group can be flushed by another goroutine.
 func (p *RowGroupWriter) WriteRecord(record *map[string]interface{}) {
 	for i, f := range p.schema.Fields {
 		f.append(&p.fieldData[i], record)
 	}
-	p.meta.NextDoc()
 	p.len++
 }
 
+// WriteColumns buffers the rows [from, to) of batch, the columns missing
+// from batch get their default value, or null for optional columns.
+func (p *RowGroupWriter) WriteColumns(batch *Batch, from, to int) {
+	var empty map[string]interface{}
+	for i := range p.schema.Fields {
+		f := &p.schema.Fields[i]
+		if c := batch.Column(f.name); c != nil {
+			p.fieldData[i].appendBatch(f, c, from, to)
+			continue
+		}
+		for j := from; j < to; j++ {
+			f.append(&p.fieldData[i], &empty)
+		}
+	}
+	p.len += to - from
+}
+
+// Close writes the buffered rows as a row group and starts the next
+// row group in the Metadata.
 func (p *RowGroupWriter) Close() (err error) {
+	p.meta.NextDocs(int64(p.len))
 	for i, f := range p.schema.Fields {
-	  f.write(p.w, p.meta, &p.fieldData[i])
+		if e := f.write(p.w, p.meta, &p.fieldData[i]); e != nil && err == nil {
+			err = e
+		}
 	}
+	p.meta.StartRowGroup(p.schema.PFields...)
 	p.len = 0
 	return err
 }
+
+func (p *RowGroupWriter) reset() {
+	for i, f := range p.schema.Fields {
+		f.reset(&p.fieldData[i])
+	}
+	p.len = 0
+}
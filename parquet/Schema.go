@@ -1,7 +1,7 @@
 package parquet
 
 import (
-	"encoding/binary"
+	"encoding/base64"
 	"fmt"
 	sh "github.com/houkx/parquet-go/parquet/schema"
 	"github.com/json-iterator/go"
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Schema struct {
@@ -23,12 +24,30 @@ type SchemaField struct {
 	name         string
 	fieldType    sh.Type
 	defaultValue interface{}
+	optional     bool
+	// hasDefault is set when the schema gives the default value, a reader
+	// schema fills a required field the file does not have with it.
+	hasDefault bool
+	// typeLength is the size of the values of FIXED_LEN_BYTE_ARRAY columns.
+	typeLength int
+	// binary BYTE_ARRAY columns hold []byte values rather than strings,
+	// Values keeps them as strings all the same.
+	binary bool
+	// enum holds the symbols of enum columns, it is nil for other columns.
+	enum *enumType
+	// intType is the logical type of small and unsigned integer columns,
+	// whose values are kept as the bits of int32 or int64 values.
+	intType *sh.IntType
+	// aliases are the former names of the field, a reader schema reads
+	// the column of a file with one of these names when it has no column
+	// with the name of the field.
+	aliases []string
 	RequiredField
 	makeValues func(max int) *Values
 	append     func(values *Values, record *map[string]interface{})
 	write       func(w io.Writer, meta *Metadata, values *Values) error
 	reset       func(values *Values)
-	intSizePool sync.Pool
+	intSizePool *sync.Pool
 }
 type Values struct {
 	strs []string
@@ -37,6 +56,37 @@ type Values struct {
 	f64s []float64
 	i64s []int64
 	boos []bool
+	// bins are the values of FIXED_LEN_BYTE_ARRAY and INT96 columns.
+	bins [][]byte
+	// defs are the definition levels of optional columns, a null has
+	// level 0 and a zero value in the slice of its type.
+	defs []uint8
+}
+
+// value returns the i-th value of a column of type t.
+func (v *Values) value(t sh.Type, i int) interface{} {
+	if v.defs != nil && v.defs[i] == 0 {
+		return nil
+	}
+	switch t {
+	case sh.Type_BYTE_ARRAY:
+		return v.strs[i]
+	case sh.Type_INT32:
+		return v.i32s[i]
+	case sh.Type_FLOAT:
+		return v.f32s[i]
+	case sh.Type_DOUBLE:
+		return v.f64s[i]
+	case sh.Type_INT64:
+		return v.i64s[i]
+	case sh.Type_BOOLEAN:
+		return v.boos[i]
+	case sh.Type_FIXED_LEN_BYTE_ARRAY:
+		return v.bins[i]
+	case sh.Type_INT96:
+		return Int96ToTime(v.bins[i])
+	}
+	return nil
 }
 
 func (p *Schema) GetJsonMap() *map[string]interface{} {
@@ -49,10 +99,201 @@ func (p *Schema) ReturnJsonMap(m *map[string]interface{}) {
 	}
 	p.jsonMapPool.Put(m)
 }
+// Field returns the field with the given name, nil if there is none.
+func (p *Schema) Field(name string) *SchemaField {
+	for i := range p.Fields {
+		if p.Fields[i].name == name {
+			return &p.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Without returns a copy of the schema with the given fields removed.
+func (p *Schema) Without(names ...string) *Schema {
+	drop := make(map[string]bool, len(names))
+	for _, name := range names {
+		drop[name] = true
+	}
+	fs := make([]SchemaField, 0, len(p.Fields))
+	pfs := make([]Field, 0, len(p.PFields))
+	for i, f := range p.Fields {
+		if !drop[f.name] {
+			fs = append(fs, f)
+			pfs = append(pfs, p.PFields[i])
+		}
+	}
+	return newSchema(fs, pfs, p.CompressionCodec)
+}
+
+// Type returns the parquet type of the field.
+func (f *SchemaField) Type() sh.Type {
+	return f.fieldType
+}
+
+// Optional reports whether the column may hold nulls.
+func (f *SchemaField) Optional() bool {
+	return f.optional
+}
+
+// IntType returns the logical type of an INT32 or INT64 column with an
+// integer annotation, nil for other columns.
+func (f *SchemaField) IntType() *sh.IntType {
+	return f.intType
+}
+
+// Binary reports whether the BYTE_ARRAY column holds []byte values
+// rather than strings.
+func (f *SchemaField) Binary() bool {
+	return f.binary
+}
+
+// Value returns the value of the field in record, converted the
+// same way as when the record is written.
+func (f *SchemaField) Value(record *map[string]interface{}) interface{} {
+	if f.optional && (*record)[f.name] == nil {
+		return nil
+	}
+	return f.convert((*record)[f.name])
+}
+
+// convert converts v to the type of the field, or returns the default
+// value if it cannot be converted.
+func (f *SchemaField) convert(v interface{}) interface{} {
+	if f.fieldType == sh.Type_FIXED_LEN_BYTE_ARRAY {
+		return convertFixed(v, f.typeLength, f.defaultValue)
+	}
+	if f.binary {
+		return convertBinary(v, f.defaultValue)
+	}
+	if f.intType != nil {
+		return convertInt(v, f.intType, f.defaultValue)
+	}
+	return convertDataByType(f.fieldType, v, f.defaultValue)
+}
+
+// value returns the i-th value of the field in values, as it is returned
+// by the reader.
+func (f *SchemaField) value(values *Values, i int) interface{} {
+	v := values.value(f.fieldType, i)
+	switch x := v.(type) {
+	case string:
+		if f.binary {
+			return []byte(x)
+		}
+	case int32:
+		if f.intType != nil {
+			return intValue(f.intType, int64(x))
+		}
+	case int64:
+		if f.intType != nil {
+			return intValue(f.intType, x)
+		}
+	}
+	return v
+}
+
+// column returns the description of the column of the field.
+func (f *SchemaField) column() SchemaColumn {
+	c := SchemaColumn{Name: f.name, Type: f.fieldType, Optional: f.optional, Length: f.typeLength, Binary: f.binary, Int: f.intType, Aliases: f.aliases}
+	if f.enum != nil {
+		c.Enum, c.Symbols, c.UnknownSymbols = true, f.enum.symbols, f.enum.policy
+	}
+	return c
+}
+
 func NewSchema(avroSchema string, compression sh.CompressionCodec) (schema *Schema, err error) {
 	return getSchemaFromAvroSchema(avroSchema, compression)
 }
 
+// NewSchemaFromFileMetaData creates the Schema of a file from its footer,
+// so that its rows can be written again by a ParquetWriter.
+// Its BYTE_ARRAY columns are string columns unless they are annotated
+// otherwise, older writers do not annotate their string columns.
+func NewSchemaFromFileMetaData(footer *sh.FileMetaData, compression sh.CompressionCodec) (*Schema, error) {
+	return schemaFromFooter(footer, compression, false)
+}
+
+// schemaFromFooter creates the Schema of a file, its BYTE_ARRAY columns
+// without annotation are binary columns if binary is set.
+func schemaFromFooter(footer *sh.FileMetaData, compression sh.CompressionCodec, binary bool) (*Schema, error) {
+	cols := make([]SchemaColumn, 0, len(footer.Schema))
+	for _, se := range footer.Schema[1:] {
+		if se.Type == nil {
+			return nil, fmt.Errorf("nested column %s is not supported", se.Name)
+		}
+		if se.GetRepetitionType() == sh.FieldRepetitionType_REPEATED {
+			return nil, fmt.Errorf("column %s is repeated, only required and optional columns are supported", se.Name)
+		}
+		cols = append(cols, elementColumn(se, binary))
+	}
+	return NewSchemaFromColumns(cols, compression)
+}
+
+// elementColumn returns the description of the leaf column se of a footer,
+// repeated columns are described as required ones.  A BYTE_ARRAY column
+// without annotation holds strings unless binary is set.
+func elementColumn(se *sh.SchemaElement, binary bool) SchemaColumn {
+	var it *sh.IntType
+	if *se.Type == sh.Type_INT32 || *se.Type == sh.Type_INT64 {
+		it = intTypeOf(se)
+	}
+	return SchemaColumn{
+		Name:     se.Name,
+		Type:     *se.Type,
+		Optional: se.GetRepetitionType() == sh.FieldRepetitionType_OPTIONAL,
+		Length:   int(se.GetTypeLength()),
+		Binary:   binary && *se.Type == sh.Type_BYTE_ARRAY && !se.IsSetConvertedType() && !se.IsSetLogicalType(),
+		Enum:     se.GetConvertedType() == sh.ConvertedType_ENUM || se.IsSetLogicalType() && se.LogicalType.IsSetENUM(),
+		Int:      it,
+	}
+}
+
+// SchemaColumn describes a flat column of a Schema.  Length is the
+// size of the values of a FIXED_LEN_BYTE_ARRAY column.  A BYTE_ARRAY
+// column holds strings unless Binary is set, then it holds []byte values
+// and has no STRING annotation.  Enum makes it a column of the Symbols
+// of an enum, writers check the values against them unless there are
+// none, UnknownSymbols is what they do with the others.  Int annotates an
+// INT32 column with 8, 16 or 32 bits wide integers or an INT64 column with
+// 64 bits wide integers, unsigned ones hold uint8 to uint64 values.
+type SchemaColumn struct {
+	Name           string
+	Type           sh.Type
+	Optional       bool
+	Length         int
+	Binary         bool
+	Enum           bool
+	Symbols        []string
+	UnknownSymbols EnumPolicy
+	Int            *sh.IntType
+	Aliases        []string
+}
+
+// NewSchemaFromColumns creates a Schema with the given columns, they get
+// the same default values as the columns of an avro schema.
+func NewSchemaFromColumns(cols []SchemaColumn, compression sh.CompressionCodec) (*Schema, error) {
+	fs := make([]SchemaField, 0, len(cols))
+	pfs := make([]Field, 0, len(cols))
+	for _, c := range cols {
+		if c.Type == sh.Type_FIXED_LEN_BYTE_ARRAY && c.Length <= 0 {
+			return nil, fmt.Errorf("column %s has no length", c.Name)
+		}
+		if c.Int != nil {
+			if _, ok := intConvertedTypes[*c.Int]; !ok || intPhysicalType(c.Int) != c.Type {
+				return nil, fmt.Errorf("column %s of type %s cannot hold %d bits wide integers", c.Name, c.Type, c.Int.BitWidth)
+			}
+		}
+		f, pf := newSchemaField(c, defaultValue(c, nil), compression)
+		if pf.Type == nil {
+			return nil, fmt.Errorf("column %s has unsupported type %s", c.Name, c.Type)
+		}
+		fs = append(fs, f)
+		pfs = append(pfs, pf)
+	}
+	return newSchema(fs, pfs, compression), nil
+}
+
 func getSchemaFromAvroSchema(avroSchema string, compression sh.CompressionCodec) (sc *Schema, err error) {
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
 	var fieldsAny = json.Get([]byte(avroSchema), "fields")
@@ -66,189 +307,541 @@ func getSchemaFromAvroSchema(avroSchema string, compression sh.CompressionCodec)
 		pfs := make([]Field, 0, len(fields))
 		for _, m := range fields {
 			if m, ok := m.(map[string]interface{}); ok {
-				var fieldTypeStr = m["type"].(string)
+				fieldTypeStr, attrs, optional := avroFieldType(m["type"])
 				t, e := avroTypeToParquetType(strings.ToLower(fieldTypeStr))
 				if e == nil {
-					var fieldName = m["name"].(string)
-					var defV = m["default"]
-					if defV != nil {
-						defV = convertDataByType(t, defV, defVal(t))
-					} else {
-						defV = defVal(t)
-					}
-					f := SchemaField{
-						name:          fieldName,
-						fieldType:     t,
-						defaultValue:  defV,
-						RequiredField: RequiredField{Paths: []string{fieldName}, Codec: compression},
+					c := SchemaColumn{Name: m["name"].(string), Type: t, Optional: optional, Binary: fieldTypeStr == "bytes"}
+					defV := m["default"]
+					aliases, _ := m["aliases"].([]interface{})
+					for _, a := range aliases {
+						c.Aliases = append(c.Aliases, fmt.Sprint(a))
 					}
-					pf := Field{
-						Name:           f.Name(),
-						Path:           f.Path(),
-						RepetitionType: RepetitionRequired,
-						Types:          []int{0},
-					}
-					switch t {
-					case sh.Type_BYTE_ARRAY:
-						pf.Type = StringType
-						f.reset = func(values *Values) {
-							values.strs = values.strs[:0]
-						}
-						f.makeValues = func(max int) *Values {
-							return &Values{strs: make([]string, 0, max)}
-						}
-						f.append = func(values *Values, record *map[string]interface{}) {
-							m := *record
-							fv, _ := m[f.name]
-							val := convertDataByType(f.fieldType, fv, f.defaultValue)
-							values.strs = append(values.strs, val.(string))
-						}
-						f.intSizePool = sync.Pool{
-							New: func() interface{} { return make([]byte, 4) },
-						}
-						f.write = func(w io.Writer, meta *Metadata, values *Values) error {
-							var buf = GetBuffer()
-							sizeBuf := f.intSizePool.Get().([]byte)
-							defer func() { PutBuffer(buf); f.intSizePool.Put(sizeBuf); f.reset(values) }()
-							size := len(values.strs)
-							order := binary.LittleEndian
-							for _, str := range values.strs {
-								order.PutUint32(sizeBuf, uint32(len(str)))
-								buf.Write(sizeBuf)
-								buf.WriteString(str)
-							}
-							return f.DoWrite(w, meta, buf.Bytes(), size, nil)
-						}
-					case sh.Type_INT32:
-						pf.Type = Int32Type
-						f.reset = func(values *Values) {
-							values.i32s = values.i32s[:0]
-						}
-						f.makeValues = func(max int) *Values {
-							return &Values{i32s: make([]int32, 0, max)}
-						}
-						f.append = func(values *Values, record *map[string]interface{}) {
-							m := *record
-							fv, _ := m[f.name]
-							val := convertDataByType(f.fieldType, fv, f.defaultValue)
-							values.i32s = append(values.i32s, val.(int32))
-						}
-						f.write = func(w io.Writer, meta *Metadata, values *Values) error {
-							var buf = GetBuffer()
-							defer func() { PutBuffer(buf); f.reset(values) }()
-							size := len(values.i32s)
-							WriteI32s(buf, binary.LittleEndian, values.i32s)
-							return f.DoWrite(w, meta, buf.Bytes(), size, nil)
-						}
-					case sh.Type_FLOAT:
-						pf.Type = Float32Type
-						f.reset = func(values *Values) {
-							values.f32s = values.f32s[:0]
-						}
-						f.makeValues = func(max int) *Values {
-							return &Values{f32s: make([]float32, 0, max)}
-						}
-						f.append = func(values *Values, record *map[string]interface{}) {
-							m := *record
-							fv, _ := m[f.name]
-							val := convertDataByType(f.fieldType, fv, f.defaultValue)
-							values.f32s = append(values.f32s, val.(float32))
+					if lt, ok := attrs["logicalType"].(string); ok {
+						if c.Int, err = avroIntType(t, lt); err != nil {
+							return nil, fmt.Errorf("field %s: %s", c.Name, err)
 						}
-						f.write = func(w io.Writer, meta *Metadata, values *Values) error {
-							var buf = GetBuffer()
-							defer func() { PutBuffer(buf); f.reset(values) }()
-							size := len(values.f32s)
-							WriteF32s(buf, binary.LittleEndian, values.f32s)
-							return f.DoWrite(w, meta, buf.Bytes(), size, nil)
-						}
-					case sh.Type_DOUBLE:
-						pf.Type = Float64Type
-						f.reset = func(values *Values) {
-							values.f64s = values.f64s[:0]
-						}
-						f.makeValues = func(max int) *Values {
-							return &Values{f64s: make([]float64, 0, max)}
-						}
-						f.append = func(values *Values, record *map[string]interface{}) {
-							m := *record
-							fv, _ := m[f.name]
-							val := convertDataByType(f.fieldType, fv, f.defaultValue)
-							values.f64s = append(values.f64s, val.(float64))
-						}
-						f.write = func(w io.Writer, meta *Metadata, values *Values) error {
-							var buf = GetBuffer()
-							defer func() { PutBuffer(buf); f.reset(values) }()
-							size := len(values.f64s)
-							WriteF64s(buf, binary.LittleEndian, values.f64s)
-							return f.DoWrite(w, meta, buf.Bytes(), size, nil)
-						}
-					case sh.Type_INT64:
-						pf.Type = Int64Type
-						f.reset = func(values *Values) {
-							values.i64s = values.i64s[:0]
-						}
-						f.makeValues = func(max int) *Values {
-							return &Values{i64s: make([]int64, 0, max)}
-						}
-						f.append = func(values *Values, record *map[string]interface{}) {
-							m := *record
-							fv, _ := m[f.name]
-							val := convertDataByType(f.fieldType, fv, f.defaultValue)
-							values.i64s = append(values.i64s, val.(int64))
-						}
-						f.write = func(w io.Writer, meta *Metadata, values *Values) error {
-							var buf = GetBuffer()
-							defer func() { PutBuffer(buf); f.reset(values) }()
-							size := len(values.i64s)
-							WriteI64s(buf, binary.LittleEndian, values.i64s)
-							return f.DoWrite(w, meta, buf.Bytes(), size, nil)
-						}
-					case sh.Type_BOOLEAN:
-						pf.Type = BoolType
-						f.reset = func(values *Values) {
-							values.boos = values.boos[:0]
+					}
+					if fieldTypeStr == "enum" {
+						c.Enum = true
+						symbols, _ := attrs["symbols"].([]interface{})
+						for _, s := range symbols {
+							c.Symbols = append(c.Symbols, fmt.Sprint(s))
 						}
-						f.makeValues = func(max int) *Values {
-							return &Values{boos: make([]bool, 0, max)}
+						if len(c.Symbols) == 0 {
+							return nil, fmt.Errorf("enum field %s has no symbols", c.Name)
 						}
-						f.append = func(values *Values, record *map[string]interface{}) {
-							m := *record
-							fv, _ := m[f.name]
-							val := convertDataByType(f.fieldType, fv, f.defaultValue)
-							values.boos = append(values.boos, val.(bool))
+						if defV == nil {
+							defV = attrs["default"]
 						}
-						f.write = func(w io.Writer, meta *Metadata, values *Values) error {
-							ln := len(values.boos)
-							n := (ln + 7) / 8
-							rawBuf := make([]byte, n)
-
-							for i := 0; i < ln; i++ {
-								if values.boos[i] {
-									rawBuf[i/8] = rawBuf[i/8] | (1 << uint32(i%8))
-								}
-							}
-							f.reset(values)
-							return f.DoWrite(w, meta, rawBuf, ln, nil)
+					}
+					if t == sh.Type_FIXED_LEN_BYTE_ARRAY {
+						size, _ := attrs["size"].(float64)
+						if size <= 0 {
+							return nil, fmt.Errorf("fixed field %s has no size", c.Name)
 						}
+						c.Length = int(size)
 					}
+					f, pf := newSchemaField(c, defaultValue(c, defV), compression)
+					f.hasDefault = defV != nil
 					fs = append(fs, f)
 					pfs = append(pfs, pf)
 				}
 			}
 		}
-		sc = &Schema{Fields: fs, PFields: pfs, CompressionCodec: compression,
-			jsonMapPool: sync.Pool{
-				New: func() interface{} {
-					return new(map[string]interface{})
-				},
-			},
-		}
+		sc = newSchema(fs, pfs, compression)
 	}
 	return sc, err
 }
+
+// avroFieldType returns the name of the type of an avro field and the
+// attributes of a complex type such as fixed, a union of null and
+// another type is an optional field of the other type.
+func avroFieldType(t interface{}) (name string, attrs map[string]interface{}, optional bool) {
+	switch t := t.(type) {
+	case string:
+		return t, nil, false
+	case map[string]interface{}:
+		name, _ := t["type"].(string)
+		return name, t, false
+	case []interface{}:
+		if len(t) != 2 {
+			return "", nil, false
+		}
+		switch {
+		case t[0] == "null":
+			name, attrs, _ = avroFieldType(t[1])
+			return name, attrs, true
+		case t[1] == "null":
+			name, attrs, _ = avroFieldType(t[0])
+			return name, attrs, true
+		}
+	}
+	return "", nil, false
+}
+
+// defaultValue returns the default value of the column c, which is v
+// converted to the type of the column unless v is nil.
+func defaultValue(c SchemaColumn, v interface{}) interface{} {
+	def := defVal(c.Type)
+	if c.Type == sh.Type_FIXED_LEN_BYTE_ARRAY {
+		def = make([]byte, c.Length)
+		if v != nil {
+			return convertFixed(v, c.Length, def)
+		}
+	}
+	if c.Enum && len(c.Symbols) > 0 {
+		def = c.Symbols[0]
+	}
+	if c.Int != nil {
+		def = convertInt(DefaultNumber, c.Int, intValue(c.Int, 0))
+		if v != nil {
+			return convertInt(v, c.Int, def)
+		}
+		return def
+	}
+	if c.Binary {
+		def = []byte{}
+		if v != nil {
+			return convertBinary(v, def)
+		}
+	}
+	if v != nil {
+		return convertDataByType(c.Type, v, def)
+	}
+	return def
+}
+
+// newSchemaField creates the field of a required or optional column and
+// its parquet metadata.
+func newSchemaField(c SchemaColumn, defV interface{}, compression sh.CompressionCodec, opts ...func(*RequiredField)) (SchemaField, Field) {
+	t, optional := c.Type, c.Optional
+	f := SchemaField{
+		name:         c.Name,
+		fieldType:    t,
+		defaultValue: defV,
+		optional:     optional,
+		typeLength:   c.Length,
+		binary:       c.Binary && t == sh.Type_BYTE_ARRAY,
+		aliases:      c.Aliases,
+	}
+	if c.Int != nil && intPhysicalType(c.Int) == t {
+		f.intType = c.Int
+	}
+	encoding := sh.Encoding_PLAIN
+	if c.Enum && t == sh.Type_BYTE_ARRAY {
+		f.enum = newEnumType(c.Symbols, c.UnknownSymbols)
+		encoding = sh.Encoding_RLE_DICTIONARY
+	}
+	f.RequiredField = NewRequiredField([]string{c.Name}, append([]func(*RequiredField){func(r *RequiredField) { r.Codec, r.Encoding = compression, encoding }}, opts...)...)
+	pf := Field{
+		Name:           f.Name(),
+		Path:           f.Path(),
+		RepetitionType: RepetitionRequired,
+		Types:          []int{0},
+	}
+	switch t {
+	case sh.Type_BYTE_ARRAY:
+		pf.Type = StringType
+		if f.binary {
+			pf.Type = BinaryType
+		} else if f.enum != nil {
+			pf.Type = EnumType
+		}
+		f.reset = func(values *Values) {
+			values.strs = values.strs[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{strs: make([]string, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			if f.binary {
+				values.strs = append(values.strs, string(f.convert(fv).([]byte)))
+				return
+			}
+			val := convertDataByType(f.fieldType, fv, f.defaultValue).(string)
+			if f.enum != nil {
+				val = f.enumValue(val)
+			}
+			values.strs = append(values.strs, val)
+		}
+		f.intSizePool = &sync.Pool{
+			New: func() interface{} { return make([]byte, 4) },
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			size := len(values.strs)
+			stats := newStringStats()
+			for _, str := range values.strs {
+				stats.add(str)
+			}
+			if f.Encoding == sh.Encoding_RLE_DICTIONARY {
+				if err := f.writeDictionary(w, meta, values, buf); err != nil {
+					return err
+				}
+			} else {
+				f.encode(buf, values)
+			}
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+		}
+	case sh.Type_INT32:
+		pf.Type = Int32Type
+		if f.intType != nil {
+			pf.Type = IntegerType(f.intType.BitWidth, f.intType.IsSigned)
+		}
+		f.reset = func(values *Values) {
+			values.i32s = values.i32s[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{i32s: make([]int32, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			if f.intType != nil {
+				values.i32s = append(values.i32s, int32(intBits(f.convert(fv))))
+				return
+			}
+			val := convertDataByType(f.fieldType, fv, f.defaultValue)
+			values.i32s = append(values.i32s, val.(int32))
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			size := len(values.i32s)
+			f.encode(buf, values)
+			if f.intType != nil && !f.intType.IsSigned {
+				stats := newUint32stats()
+				for _, v := range values.i32s {
+					stats.add(uint32(v))
+				}
+				return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+			}
+			stats := newInt32stats()
+			for _, v := range values.i32s {
+				stats.add(v)
+			}
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+		}
+	case sh.Type_FLOAT:
+		pf.Type = Float32Type
+		f.reset = func(values *Values) {
+			values.f32s = values.f32s[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{f32s: make([]float32, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			val := convertDataByType(f.fieldType, fv, f.defaultValue)
+			values.f32s = append(values.f32s, val.(float32))
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			size := len(values.f32s)
+			f.encode(buf, values)
+			stats := newFloat32stats()
+			for _, v := range values.f32s {
+				stats.add(v)
+			}
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+		}
+	case sh.Type_DOUBLE:
+		pf.Type = Float64Type
+		f.reset = func(values *Values) {
+			values.f64s = values.f64s[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{f64s: make([]float64, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			val := convertDataByType(f.fieldType, fv, f.defaultValue)
+			values.f64s = append(values.f64s, val.(float64))
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			size := len(values.f64s)
+			f.encode(buf, values)
+			stats := newFloat64stats()
+			for _, v := range values.f64s {
+				stats.add(v)
+			}
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+		}
+	case sh.Type_INT64:
+		pf.Type = Int64Type
+		if f.intType != nil {
+			pf.Type = IntegerType(f.intType.BitWidth, f.intType.IsSigned)
+		}
+		f.reset = func(values *Values) {
+			values.i64s = values.i64s[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{i64s: make([]int64, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			if f.intType != nil {
+				values.i64s = append(values.i64s, intBits(f.convert(fv)))
+				return
+			}
+			val := convertDataByType(f.fieldType, fv, f.defaultValue)
+			values.i64s = append(values.i64s, val.(int64))
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			size := len(values.i64s)
+			f.encode(buf, values)
+			if f.intType != nil && !f.intType.IsSigned {
+				stats := newUint64stats()
+				for _, v := range values.i64s {
+					stats.add(uint64(v))
+				}
+				return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+			}
+			stats := newInt64stats()
+			for _, v := range values.i64s {
+				stats.add(v)
+			}
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+		}
+	case sh.Type_BOOLEAN:
+		pf.Type = BoolType
+		f.reset = func(values *Values) {
+			values.boos = values.boos[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{boos: make([]bool, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			val := convertDataByType(f.fieldType, fv, f.defaultValue)
+			values.boos = append(values.boos, val.(bool))
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			f.encode(buf, values)
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), len(values.boos), nil)
+		}
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		if t == sh.Type_INT96 {
+			pf.Type = Int96Type
+		} else {
+			pf.Type = FixedLenByteArrayType(f.typeLength)
+		}
+		f.reset = func(values *Values) {
+			values.bins = values.bins[:0]
+		}
+		f.makeValues = func(max int) *Values {
+			return &Values{bins: make([][]byte, 0, max)}
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			m := *record
+			fv, _ := m[f.name]
+			switch val := f.convert(fv).(type) {
+			case []byte:
+				values.bins = append(values.bins, val)
+			case time.Time:
+				values.bins = append(values.bins, TimeToInt96(val))
+			}
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			var buf = GetBuffer()
+			defer func() { PutBuffer(buf); f.reset(values) }()
+			size := len(values.bins)
+			f.encode(buf, values)
+			// INT96 values have no defined sort order, they get no min and max.
+			var stats Stats
+			if t == sh.Type_FIXED_LEN_BYTE_ARRAY {
+				s := newStringStats()
+				for _, b := range values.bins {
+					s.add(string(b))
+				}
+				stats = s
+			}
+			return f.doWrite(w, meta, values.defs, buf.Bytes(), size, stats)
+		}
+	}
+	if optional {
+		pf.RepetitionType = RepetitionOptional
+		pf.Types = []int{1}
+		makeValues, appendValue, write, reset := f.makeValues, f.append, f.write, f.reset
+		f.makeValues = func(max int) *Values {
+			values := makeValues(max)
+			values.defs = make([]uint8, 0, max)
+			return values
+		}
+		f.append = func(values *Values, record *map[string]interface{}) {
+			if (*record)[f.name] == nil {
+				values.defs = append(values.defs, 0)
+				values.appendZero(f.fieldType)
+				return
+			}
+			values.defs = append(values.defs, 1)
+			appendValue(values, record)
+		}
+		f.write = func(w io.Writer, meta *Metadata, values *Values) error {
+			values.dropNulls(f.fieldType)
+			return write(w, meta, values)
+		}
+		f.reset = func(values *Values) {
+			values.defs = values.defs[:0]
+			reset(values)
+		}
+	}
+	return f, pf
+}
+
+// doWrite writes a page of count PLAIN encoded values, optional columns
+// prefix them with the definition levels defs.
+func (f *SchemaField) doWrite(w io.Writer, meta *Metadata, defs []uint8, vals []byte, count int, stats Stats) error {
+	if !f.optional {
+		return f.DoWrite(w, meta, vals, count, stats)
+	}
+	if stats != nil {
+		stats = nullCountStats{Stats: stats, nulls: int64(len(defs) - count), empty: count == 0}
+	}
+	o := OptionalField{
+		Defs:        defs,
+		pth:         f.Paths,
+		MaxLevels:   MaxLevel{Def: 1},
+		compression: f.Codec,
+		encoding:    f.Encoding,
+	}
+	return o.DoWrite(w, meta, vals, len(defs), stats)
+}
+
+// nullCountStats adds the number of nulls of a page to its Stats, a
+// page of nulls has no min and max.
+type nullCountStats struct {
+	Stats
+	nulls int64
+	empty bool
+}
+
+func (s nullCountStats) NullCount() *int64 { return &s.nulls }
+
+func (s nullCountStats) Min() []byte {
+	if s.empty {
+		return nil
+	}
+	return s.Stats.Min()
+}
+
+func (s nullCountStats) Max() []byte {
+	if s.empty {
+		return nil
+	}
+	return s.Stats.Max()
+}
+
+// appendZero appends the zero value of type t, which stands for a null.
+func (v *Values) appendZero(t sh.Type) {
+	switch t {
+	case sh.Type_BYTE_ARRAY:
+		v.strs = append(v.strs, "")
+	case sh.Type_INT32:
+		v.i32s = append(v.i32s, 0)
+	case sh.Type_FLOAT:
+		v.f32s = append(v.f32s, 0)
+	case sh.Type_DOUBLE:
+		v.f64s = append(v.f64s, 0)
+	case sh.Type_INT64:
+		v.i64s = append(v.i64s, 0)
+	case sh.Type_BOOLEAN:
+		v.boos = append(v.boos, false)
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		v.bins = append(v.bins, nil)
+	}
+}
+
+// appendFrom appends the i-th value of type t of src.
+func (v *Values) appendFrom(t sh.Type, src *Values, i int) {
+	switch t {
+	case sh.Type_BYTE_ARRAY:
+		v.strs = append(v.strs, src.strs[i])
+	case sh.Type_INT32:
+		v.i32s = append(v.i32s, src.i32s[i])
+	case sh.Type_FLOAT:
+		v.f32s = append(v.f32s, src.f32s[i])
+	case sh.Type_DOUBLE:
+		v.f64s = append(v.f64s, src.f64s[i])
+	case sh.Type_INT64:
+		v.i64s = append(v.i64s, src.i64s[i])
+	case sh.Type_BOOLEAN:
+		v.boos = append(v.boos, src.boos[i])
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		v.bins = append(v.bins, src.bins[i])
+	}
+}
+
+// dropNulls removes the nulls from the values of type t in place, so only
+// the values that are written remain.  The definition levels are kept.
+func (v *Values) dropNulls(t sh.Type) {
+	n := 0
+	for i, d := range v.defs {
+		if d == 0 {
+			continue
+		}
+		switch t {
+		case sh.Type_BYTE_ARRAY:
+			v.strs[n] = v.strs[i]
+		case sh.Type_INT32:
+			v.i32s[n] = v.i32s[i]
+		case sh.Type_FLOAT:
+			v.f32s[n] = v.f32s[i]
+		case sh.Type_DOUBLE:
+			v.f64s[n] = v.f64s[i]
+		case sh.Type_INT64:
+			v.i64s[n] = v.i64s[i]
+		case sh.Type_BOOLEAN:
+			v.boos[n] = v.boos[i]
+		case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+			v.bins[n] = v.bins[i]
+		}
+		n++
+	}
+	switch t {
+	case sh.Type_BYTE_ARRAY:
+		v.strs = v.strs[:n]
+	case sh.Type_INT32:
+		v.i32s = v.i32s[:n]
+	case sh.Type_FLOAT:
+		v.f32s = v.f32s[:n]
+	case sh.Type_DOUBLE:
+		v.f64s = v.f64s[:n]
+	case sh.Type_INT64:
+		v.i64s = v.i64s[:n]
+	case sh.Type_BOOLEAN:
+		v.boos = v.boos[:n]
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		v.bins = v.bins[:n]
+	}
+}
+
+func newSchema(fs []SchemaField, pfs []Field, compression sh.CompressionCodec) *Schema {
+	return &Schema{Fields: fs, PFields: pfs, CompressionCodec: compression,
+		jsonMapPool: sync.Pool{
+			New: func() interface{} {
+				return new(map[string]interface{})
+			},
+		},
+	}
+}
 func avroTypeToParquetType(avroType string) (t sh.Type, err error) {
 	switch avroType {
-	case "string":
+	case "string", "bytes", "enum":
 		return sh.Type_BYTE_ARRAY, nil
 	case "int":
 		return sh.Type_INT32, nil
@@ -260,6 +853,11 @@ func avroTypeToParquetType(avroType string) (t sh.Type, err error) {
 		return sh.Type_INT64, nil
 	case "boolean":
 		return sh.Type_BOOLEAN, nil
+	case "fixed":
+		return sh.Type_FIXED_LEN_BYTE_ARRAY, nil
+	case "int96":
+		// not an avro type, it is for the timestamps of Impala and Hive.
+		return sh.Type_INT96, nil
 	}
 	return sh.Type(0), fmt.Errorf("not a valid Type string")
 }
@@ -280,7 +878,9 @@ func convertDataByType(dataType sh.Type, val, defV interface{}) interface{} {
 		}
 		val = val.(string)
 	case sh.Type_INT32:
-		if vt == reflect.Float64 {
+		if vt == reflect.Int32 {
+			val = val.(int32)
+		} else if vt == reflect.Float64 {
 			val = int32(val.(float64))
 		} else if vt == reflect.String {
 			num, e := strconv.Atoi(val.(string))
@@ -293,7 +893,9 @@ func convertDataByType(dataType sh.Type, val, defV interface{}) interface{} {
 			val = defV
 		}
 	case sh.Type_FLOAT:
-		if vt == reflect.Float64 {
+		if vt == reflect.Float32 {
+			val = val.(float32)
+		} else if vt == reflect.Float64 {
 			val = float32(val.(float64))
 		} else if vt == reflect.String {
 			num, e := strconv.ParseFloat(val.(string), 32)
@@ -343,9 +945,72 @@ func convertDataByType(dataType sh.Type, val, defV interface{}) interface{} {
 				val = defV
 			}
 		}
+	case sh.Type_INT96:
+		switch x := val.(type) {
+		case time.Time:
+			val = x.UTC()
+		case string:
+			t, e := time.Parse(time.RFC3339Nano, x)
+			if e != nil {
+				val = defV
+			} else {
+				val = t.UTC()
+			}
+		case []byte:
+			if len(x) != 12 {
+				val = defV
+			} else {
+				val = Int96ToTime(x)
+			}
+		case [12]byte:
+			val = Int96ToTime(x[:])
+		default:
+			val = defV
+		}
 	}
 	return val
 }
+
+// convertBinary converts val to the []byte value of a binary column,
+// strings are base64 encoded like in JSON.
+func convertBinary(val interface{}, defV interface{}) interface{} {
+	switch x := val.(type) {
+	case []byte:
+		return x
+	case string:
+		if b, e := base64.StdEncoding.DecodeString(x); e == nil {
+			return b
+		}
+	}
+	return defV
+}
+
+// convertFixed converts val to a FIXED_LEN_BYTE_ARRAY value of size
+// bytes.  Strings are the bytes themselves when they have the size,
+// otherwise they are base64 encoded like in JSON.
+func convertFixed(val interface{}, size int, defV interface{}) interface{} {
+	var b []byte
+	switch x := val.(type) {
+	case []byte:
+		b = x
+	case string:
+		if len(x) == size {
+			b = []byte(x)
+		} else if d, e := base64.StdEncoding.DecodeString(x); e == nil {
+			b = d
+		}
+	default:
+		rv := reflect.ValueOf(val)
+		if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b = make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+		}
+	}
+	if len(b) != size {
+		return defV
+	}
+	return b
+}
 func defVal(fieldType sh.Type) interface{} {
 	switch fieldType {
 	case sh.Type_BYTE_ARRAY:
@@ -360,6 +1025,8 @@ func defVal(fieldType sh.Type) interface{} {
 		return float64(DefaultNumber)
 	case sh.Type_BOOLEAN:
 		return false
+	case sh.Type_INT96:
+		return time.Unix(0, 0).UTC()
 	}
 	return nil
 }
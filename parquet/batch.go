@@ -0,0 +1,264 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// DefaultBatchSize is the number of rows of a Batch unless
+// ParquetReaderBatchSize sets another one.
+const DefaultBatchSize = 1024
+
+// ColumnBatch holds the values of one column for the rows of a Batch.
+// Only the slice matching Type is used, like the Values the writer
+// buffers, Bytes holds FIXED_LEN_BYTE_ARRAY values and the 12 bytes of
+// INT96 values.  Strings holds the raw bytes of Binary columns too, and
+// Int32s and Int64s the bits of the integers of Int columns.  Nulls
+// is nil for required columns, otherwise Nulls[i] tells whether row i is
+// null, the typed slice holds a zero value for it.
+type ColumnBatch struct {
+	Name     string
+	Type     sh.Type
+	Binary   bool
+	Int      *sh.IntType
+	Strings  []string
+	Int32s   []int32
+	Float32s []float32
+	Float64s []float64
+	Int64s   []int64
+	Bools    []bool
+	Bytes    [][]byte
+	Nulls    []bool
+}
+
+// Len returns the number of rows of the column.
+func (c *ColumnBatch) Len() int {
+	switch c.Type {
+	case sh.Type_BYTE_ARRAY:
+		return len(c.Strings)
+	case sh.Type_INT32:
+		return len(c.Int32s)
+	case sh.Type_FLOAT:
+		return len(c.Float32s)
+	case sh.Type_DOUBLE:
+		return len(c.Float64s)
+	case sh.Type_INT64:
+		return len(c.Int64s)
+	case sh.Type_BOOLEAN:
+		return len(c.Bools)
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		return len(c.Bytes)
+	}
+	return 0
+}
+
+// Value returns the value of row i, nil if it is null.
+func (c *ColumnBatch) Value(i int) interface{} {
+	if c.Nulls != nil && c.Nulls[i] {
+		return nil
+	}
+	v := Values{strs: c.Strings, i32s: c.Int32s, f32s: c.Float32s, f64s: c.Float64s, i64s: c.Int64s, boos: c.Bools, bins: c.Bytes}
+	f := SchemaField{fieldType: c.Type, binary: c.Binary, intType: c.Int}
+	return f.value(&v, i)
+}
+
+func (c *ColumnBatch) reset() {
+	c.Strings = c.Strings[:0]
+	c.Int32s = c.Int32s[:0]
+	c.Float32s = c.Float32s[:0]
+	c.Float64s = c.Float64s[:0]
+	c.Int64s = c.Int64s[:0]
+	c.Bools = c.Bools[:0]
+	c.Bytes = c.Bytes[:0]
+	if c.Nulls != nil {
+		c.Nulls = c.Nulls[:0]
+	}
+}
+
+// appendValues appends the values [from, to) of v.
+func (c *ColumnBatch) appendValues(v *Values, from, to int) {
+	if c.Nulls != nil {
+		for _, d := range v.defs[from:to] {
+			c.Nulls = append(c.Nulls, d == 0)
+		}
+	}
+	switch c.Type {
+	case sh.Type_BYTE_ARRAY:
+		c.Strings = append(c.Strings, v.strs[from:to]...)
+	case sh.Type_INT32:
+		c.Int32s = append(c.Int32s, v.i32s[from:to]...)
+	case sh.Type_FLOAT:
+		c.Float32s = append(c.Float32s, v.f32s[from:to]...)
+	case sh.Type_DOUBLE:
+		c.Float64s = append(c.Float64s, v.f64s[from:to]...)
+	case sh.Type_INT64:
+		c.Int64s = append(c.Int64s, v.i64s[from:to]...)
+	case sh.Type_BOOLEAN:
+		c.Bools = append(c.Bools, v.boos[from:to]...)
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		c.Bytes = append(c.Bytes, v.bins[from:to]...)
+	}
+}
+
+// Batch holds up to the batch size of rows, column by column, in the
+// order of the selected columns.
+type Batch struct {
+	Rows    int
+	Columns []ColumnBatch
+}
+
+// Column returns the named column, nil if it is not in the batch.
+func (b *Batch) Column(name string) *ColumnBatch {
+	for i := range b.Columns {
+		if b.Columns[i].Name == name {
+			return &b.Columns[i]
+		}
+	}
+	return nil
+}
+
+// ParquetReaderBatchSize sets the number of rows ReadBatch returns.
+// It is an optional arg to NewParquetReader
+func ParquetReaderBatchSize(n int) func(*ParquetReader) {
+	return func(p *ParquetReader) { p.batchSize = n }
+}
+
+// ReadBatch replaces the rows of batch with the next rows of the file,
+// reusing its slices.  It returns io.EOF once all rows have been read,
+// only the last batch may have less rows than the batch size.  Records
+// and batches can be read from the same reader, they never overlap.
+func (p *ParquetReader) ReadBatch(batch *Batch) error {
+	if len(batch.Columns) != len(p.columns) {
+		batch.Columns = make([]ColumnBatch, len(p.columns))
+	}
+	for i, c := range p.columns {
+		cb := &batch.Columns[i]
+		if cb.Name != c.field.name || cb.Type != c.field.fieldType || cb.Binary != c.field.binary || cb.Int != c.field.intType || (cb.Nulls != nil) != c.field.optional {
+			*cb = ColumnBatch{Name: c.field.name, Type: c.field.fieldType, Binary: c.field.binary, Int: c.field.intType}
+			if c.field.optional {
+				cb.Nulls = []bool{}
+			}
+		}
+		cb.reset()
+	}
+	batch.Rows = 0
+	size := p.batchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	for batch.Rows < size {
+		if err := p.nextRowGroup(); err != nil {
+			if err == io.EOF && batch.Rows > 0 {
+				return nil
+			}
+			return err
+		}
+		if p.bound != nil {
+			if p.matches() {
+				p.appendRows(batch, p.row, p.row+1)
+			}
+			p.row++
+			continue
+		}
+		n := size - batch.Rows
+		if r := p.rows - p.row; r < n {
+			n = r
+		}
+		p.appendRows(batch, p.row, p.row+n)
+		p.row += n
+	}
+	return nil
+}
+
+func (p *ParquetReader) appendRows(batch *Batch, from, to int) {
+	for i, c := range p.columns {
+		batch.Columns[i].appendValues(&c.values, from, to)
+	}
+	batch.Rows += to - from
+}
+
+// check returns an error unless the column has rows values of a field
+// of type t that fit its size and integer range, only optional fields
+// may have nulls.
+func (c *ColumnBatch) check(f *SchemaField, rows int) error {
+	if c.Type != f.fieldType {
+		return fmt.Errorf("column %s is %s, the batch has %s", f.name, f.fieldType, c.Type)
+	}
+	if n := c.Len(); n != rows {
+		return fmt.Errorf("column %s has %d values for %d rows", f.name, n, rows)
+	}
+	size := f.typeLength
+	if f.fieldType == sh.Type_INT96 {
+		size = 12
+	}
+	for i, b := range c.Bytes {
+		if len(b) != size && (c.Nulls == nil || !c.Nulls[i]) {
+			return fmt.Errorf("column %s has a value of %d bytes in row %d, expected %d", f.name, len(b), i, size)
+		}
+	}
+	if it := f.intType; it != nil && it.BitWidth < 32 {
+		for i, v := range c.Int32s {
+			if int64(v) != intBits(intValue(it, int64(v))) && (c.Nulls == nil || !c.Nulls[i]) {
+				return fmt.Errorf("column %s: %d in row %d is out of the range of %s", f.name, v, i, typeName(f.column()))
+			}
+		}
+	}
+	if f.enum != nil && f.enum.policy == EnumReject {
+		for i, s := range c.Strings {
+			if !f.enum.known(s) && (c.Nulls == nil || !c.Nulls[i]) {
+				return fmt.Errorf("column %s: %q in row %d is not a symbol of the enum", f.name, s, i)
+			}
+		}
+	}
+	if c.Nulls == nil {
+		return nil
+	}
+	if len(c.Nulls) != rows {
+		return fmt.Errorf("column %s has %d nulls for %d rows", f.name, len(c.Nulls), rows)
+	}
+	if !f.optional {
+		for _, null := range c.Nulls {
+			if null {
+				return fmt.Errorf("column %s is required but has nulls", f.name)
+			}
+		}
+	}
+	return nil
+}
+
+// appendBatch appends the rows [from, to) of c to the values of field f.
+func (v *Values) appendBatch(f *SchemaField, c *ColumnBatch, from, to int) {
+	if f.optional {
+		for i := from; i < to; i++ {
+			if c.Nulls != nil && c.Nulls[i] {
+				v.defs = append(v.defs, 0)
+			} else {
+				v.defs = append(v.defs, 1)
+			}
+		}
+	}
+	switch c.Type {
+	case sh.Type_BYTE_ARRAY:
+		if f.enum != nil {
+			for _, s := range c.Strings[from:to] {
+				v.strs = append(v.strs, f.enumValue(s))
+			}
+			break
+		}
+		v.strs = append(v.strs, c.Strings[from:to]...)
+	case sh.Type_INT32:
+		v.i32s = append(v.i32s, c.Int32s[from:to]...)
+	case sh.Type_FLOAT:
+		v.f32s = append(v.f32s, c.Float32s[from:to]...)
+	case sh.Type_DOUBLE:
+		v.f64s = append(v.f64s, c.Float64s[from:to]...)
+	case sh.Type_INT64:
+		v.i64s = append(v.i64s, c.Int64s[from:to]...)
+	case sh.Type_BOOLEAN:
+		v.boos = append(v.boos, c.Bools[from:to]...)
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		v.bins = append(v.bins, c.Bytes[from:to]...)
+	}
+}
@@ -7,19 +7,31 @@ import (
 	"sync"
 )
 
-var bytesMap = make(map[int]sync.Pool)
+var (
+	bytesMu  sync.Mutex
+	bytesMap = make(map[int]*sync.Pool)
+)
 
-func WriteI32(w io.Writer, order binary.ByteOrder, data int32) error {
-	n := 4
+// bytesPool returns the pool of n sized byte slices, it is safe
+// to be called by writers running on different goroutines.
+func bytesPool(n int) *sync.Pool {
+	bytesMu.Lock()
 	p, ok := bytesMap[n]
 	if !ok {
-		p = sync.Pool{
+		p = &sync.Pool{
 			New: func() interface{} {
 				return make([]byte, n)
 			},
 		}
 		bytesMap[n] = p
 	}
+	bytesMu.Unlock()
+	return p
+}
+
+func WriteI32(w io.Writer, order binary.ByteOrder, data int32) error {
+	n := 4
+	p := bytesPool(n)
 	bs := p.Get().([]byte)
 	order.PutUint32(bs, uint32(data))
 	_, err := w.Write(bs)
@@ -28,15 +40,7 @@ func WriteI32(w io.Writer, order binary.ByteOrder, data int32) error {
 }
 func WriteI32s(w io.Writer, order binary.ByteOrder, data []int32) error {
 	n := 4 * len(data)
-	p, ok := bytesMap[n]
-	if !ok {
-		p = sync.Pool{
-			New: func() interface{} {
-				return make([]byte, n)
-			},
-		}
-		bytesMap[n] = p
-	}
+	p := bytesPool(n)
 	bs := p.Get().([]byte)
 	for i, x := range data {
 		order.PutUint32(bs[4*i:], uint32(x))
@@ -47,15 +51,7 @@ func WriteI32s(w io.Writer, order binary.ByteOrder, data []int32) error {
 }
 func WriteI64s(w io.Writer, order binary.ByteOrder, data []int64) error {
 	n := 8 * len(data)
-	p, ok := bytesMap[n]
-	if !ok {
-		p = sync.Pool{
-			New: func() interface{} {
-				return make([]byte, n)
-			},
-		}
-		bytesMap[n] = p
-	}
+	p := bytesPool(n)
 	bs := p.Get().([]byte)
 	for i, x := range data {
 		order.PutUint64(bs[8*i:], uint64(x))
@@ -66,15 +62,7 @@ func WriteI64s(w io.Writer, order binary.ByteOrder, data []int64) error {
 }
 func WriteF32s(w io.Writer, order binary.ByteOrder, data []float32) error {
 	n := 4 * len(data)
-	p, ok := bytesMap[n]
-	if !ok {
-		p = sync.Pool{
-			New: func() interface{} {
-				return make([]byte, n)
-			},
-		}
-		bytesMap[n] = p
-	}
+	p := bytesPool(n)
 	bs := p.Get().([]byte)
 	for i, x := range data {
 		order.PutUint32(bs[4*i:], math.Float32bits(x))
@@ -85,15 +73,7 @@ func WriteF32s(w io.Writer, order binary.ByteOrder, data []float32) error {
 }
 func WriteF64s(w io.Writer, order binary.ByteOrder, data []float64) error {
 	n := 8 * len(data)
-	p, ok := bytesMap[n]
-	if !ok {
-		p = sync.Pool{
-			New: func() interface{} {
-				return make([]byte, n)
-			},
-		}
-		bytesMap[n] = p
-	}
+	p := bytesPool(n)
 	bs := p.Get().([]byte)
 	for i, x := range data {
 		order.PutUint64(bs[8*i:], math.Float64bits(x))
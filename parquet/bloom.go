@@ -0,0 +1,148 @@
+package parquet
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/houkx/parquet-go/parquet/internal/xxhash"
+	sch "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// bloomSalt are the salts of the parquet split block bloom filter.
+var bloomSalt = [8]uint32{
+	0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d,
+	0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31,
+}
+
+// BloomFilter is a parquet split block bloom filter, made of 32 byte
+// blocks of eight 32 bit words.
+type BloomFilter struct {
+	words []uint32
+}
+
+// NewBloomFilter creates an empty filter of numBytes, rounded up to
+// a multiple of the 32 byte block size.
+func NewBloomFilter(numBytes int) *BloomFilter {
+	blocks := (numBytes + 31) / 32
+	if blocks < 1 {
+		blocks = 1
+	}
+	return &BloomFilter{words: make([]uint32, 8*blocks)}
+}
+
+func (b *BloomFilter) block(hash uint64) []uint32 {
+	blocks := uint64(len(b.words) / 8)
+	i := ((hash >> 32) * blocks) >> 32
+	return b.words[8*i : 8*i+8]
+}
+
+// Insert adds a hash, as returned by BloomFilterHash, to the filter.
+func (b *BloomFilter) Insert(hash uint64) {
+	block := b.block(hash)
+	key := uint32(hash)
+	for i, salt := range bloomSalt {
+		block[i] |= 1 << ((key * salt) >> 27)
+	}
+}
+
+// Check reports whether the hash may have been inserted.
+func (b *BloomFilter) Check(hash uint64) bool {
+	block := b.block(hash)
+	key := uint32(hash)
+	for i, salt := range bloomSalt {
+		if block[i]&(1<<((key*salt)>>27)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Bytes returns the bitset as it is stored in a parquet file.
+func (b *BloomFilter) Bytes() []byte {
+	out := make([]byte, 4*len(b.words))
+	for i, w := range b.words {
+		binary.LittleEndian.PutUint32(out[4*i:], w)
+	}
+	return out
+}
+
+// BloomFilterHash hashes the PLAIN encoding of a column value, without
+// the length prefix for byte arrays.
+func BloomFilterHash(v interface{}) (uint64, error) {
+	var b []byte
+	switch x := v.(type) {
+	case int8, int16, int32, uint8, uint16, uint32:
+		b = make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(intBits(x)))
+	case int64, uint64:
+		b = make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(intBits(x)))
+	case float32:
+		b = make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, math.Float32bits(x))
+	case float64:
+		b = make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(x))
+	case string:
+		b = []byte(x)
+	case []byte:
+		b = x
+	default:
+		return 0, fmt.Errorf("values of type %T are not hashed", v)
+	}
+	return xxhash.Sum64(b), nil
+}
+
+// ReadBloomFilter reads the bloom filter at offset, which is
+// ColumnMetaData.BloomFilterOffset.
+func ReadBloomFilter(r io.ReadSeeker, offset int64) (*BloomFilter, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	p := thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: r})
+	h := sch.NewBloomFilterPageHeader()
+	if err := h.Read(p); err != nil {
+		return nil, err
+	}
+	if h.NumBytes <= 0 || h.NumBytes%32 != 0 {
+		return nil, fmt.Errorf("invalid bloom filter size %d", h.NumBytes)
+	}
+	if h.Hash == nil || !h.Hash.IsSetMURMUR3() {
+		// field 1 of the hash union, which final format versions call XXHASH
+		return nil, fmt.Errorf("unsupported bloom filter hash")
+	}
+	data := make([]byte, h.NumBytes)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	b := &BloomFilter{words: make([]uint32, h.NumBytes/4)}
+	for i := range b.words {
+		b.words[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return b, nil
+}
+
+// WriteBloomFilter writes b with its header, so that ReadBloomFilter
+// can read it at the offset it was written to.
+func WriteBloomFilter(w io.Writer, b *BloomFilter) error {
+	h := &sch.BloomFilterPageHeader{
+		NumBytes:  int32(4 * len(b.words)),
+		Algorithm: &sch.BloomFilterAlgorithm{BLOCK: sch.NewSplitBlockAlgorithm()},
+		Hash:      &sch.BloomFilterHash{MURMUR3: sch.NewMurmur3()},
+	}
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	buf, err := ts.Write(context.TODO(), h)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+	_, err = w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,152 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// Compaction holds the options of Compact.
+type Compaction struct {
+	SortBy []SortColumn
+	Codec  *sh.CompressionCodec
+	opts   []func(*ParquetWriter)
+}
+
+// CompactionSortBy sorts the rows by cols.  Sorting needs all rows of
+// all files in memory at once.
+// It is an optional arg to Compact
+func CompactionSortBy(cols ...SortColumn) func(*Compaction) {
+	return func(c *Compaction) { c.SortBy = cols }
+}
+
+// CompactionCodec sets the codec of the output, by default it is the
+// codec of the first input.
+// It is an optional arg to Compact
+func CompactionCodec(codec sh.CompressionCodec) func(*Compaction) {
+	return func(c *Compaction) { c.Codec = &codec }
+}
+
+// CompactionParquetOptions sets the options of the ParquetWriter.
+// It is an optional arg to Compact
+func CompactionParquetOptions(opts ...func(*ParquetWriter)) func(*Compaction) {
+	return func(c *Compaction) { c.opts = opts }
+}
+
+// Compact reads the rows of files, which must have identical schemas, and
+// rewrites them to w through a ParquetWriter with row groups of pageSize
+// rows.  Unlike Merge it replaces many small row groups by few large ones.
+func Compact(w io.WriteCloser, files []io.ReadSeeker, pageSize int, opts ...func(*Compaction)) error {
+	var c Compaction
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files to compact")
+	}
+
+	readers := make([]*ParquetReader, len(files))
+	for i, r := range files {
+		pr, err := NewParquetReader(r)
+		if err != nil {
+			return fmt.Errorf("file %d: %s", i, err)
+		}
+		if i > 0 {
+			if err := sameSchema(readers[0].footer.Schema, pr.footer.Schema); err != nil {
+				return fmt.Errorf("file %d: %s", i, err)
+			}
+		}
+		readers[i] = pr
+	}
+
+	schema := readers[0].Schema()
+	if c.Codec != nil {
+		var err error
+		schema, err = NewSchemaFromFileMetaData(readers[0].footer, *c.Codec)
+		if err != nil {
+			return err
+		}
+	}
+	for _, col := range c.SortBy {
+		if schema.Field(col.Name) == nil {
+			return fmt.Errorf("sort column %s is not in the schema", col.Name)
+		}
+	}
+
+	wopts := c.opts
+	if len(c.SortBy) > 0 {
+		wopts = append(wopts[:len(wopts):len(wopts)], ParquetWriterSortedBy(c.SortBy...))
+	}
+	pw := NewParquetWriter(schema, w, pageSize, wopts...)
+	if pw == nil {
+		return fmt.Errorf("unable to start parquet file")
+	}
+
+	var err error
+	if len(c.SortBy) == 0 {
+		err = copyRecords(pw, readers)
+	} else {
+		err = sortRecords(pw, readers, c.SortBy)
+	}
+	if err != nil {
+		pw.Close()
+		return err
+	}
+	return pw.Close()
+}
+
+func copyRecords(pw *ParquetWriter, readers []*ParquetReader) error {
+	var record map[string]interface{}
+	for _, pr := range readers {
+		for {
+			err := pr.Read(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := pw.Write(&record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortRecords(pw *ParquetWriter, readers []*ParquetReader, cols []SortColumn) error {
+	var records []map[string]interface{}
+	for _, pr := range readers {
+		for {
+			var record map[string]interface{}
+			err := pr.Read(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, col := range cols {
+			c := compareValues(records[i][col.Name], records[j][col.Name])
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != col.Descending
+		}
+		return false
+	})
+
+	for i := range records {
+		if err := pw.Write(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
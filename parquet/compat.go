@@ -0,0 +1,178 @@
+package parquet
+
+import (
+	"fmt"
+	"strings"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// SchemaChangeKind is the kind of an incompatible schema change.
+type SchemaChangeKind int
+
+const (
+	// TypeNarrowed is a column whose new values do not fit the old type,
+	// such as an int that became a long: the old type is narrower.
+	TypeNarrowed SchemaChangeKind = iota
+	// RequiredToOptional is a required column that became optional, the
+	// readers of the old schema get nulls.
+	RequiredToOptional
+	// RequiredRemoved is a required column that is no longer written.
+	RequiredRemoved
+	// RepetitionChanged is a column that became repeated or is no longer.
+	RepetitionChanged
+	// LogicalTypeChanged is a column of the same physical type with
+	// another annotation, such as a string that became bytes or a DATE
+	// that became a plain INT32.
+	LogicalTypeChanged
+)
+
+var schemaChangeKinds = []string{"type narrowed", "required to optional", "required removed", "repetition changed", "logical type changed"}
+
+func (k SchemaChangeKind) String() string {
+	if int(k) < len(schemaChangeKinds) {
+		return schemaChangeKinds[k]
+	}
+	return fmt.Sprintf("SchemaChangeKind(%d)", int(k))
+}
+
+// SchemaChange is a change of a column that breaks the readers of files
+// written with the old schema when they read files written with the new
+// one, see CheckSchemaChange.  Old and New describe the column in both
+// schemas, New is empty if the column was removed.
+type SchemaChange struct {
+	Column string
+	Kind   SchemaChangeKind
+	Old    string
+	New    string
+}
+
+func (c SchemaChange) String() string {
+	if c.New == "" {
+		return fmt.Sprintf("column %s: %s, it was %s", c.Column, c.Kind, c.Old)
+	}
+	return fmt.Sprintf("column %s: %s, %s became %s", c.Column, c.Kind, c.Old, c.New)
+}
+
+// SchemaElements returns the schema of the footer of the files written
+// with the schema.
+func (p *Schema) SchemaElements() []*sh.SchemaElement {
+	_, elements := schema{fields: p.PFields}.schema()
+	return elements
+}
+
+// CheckSchemaChange returns the changes from the schema before to the
+// schema after that break the readers of files written with before when
+// they read files written with after, in the order of the columns.
+// Changes the readers can resolve are not reported: new columns, removed
+// optional columns, optional columns that became required, and narrower
+// types that are promoted as by ParquetReaderSchema.
+func CheckSchemaChange(before, after *Schema) []SchemaChange {
+	return CheckSchemaElements(before.SchemaElements(), after.SchemaElements())
+}
+
+// CheckSchemaElements is CheckSchemaChange for the schemas of footers,
+// such as the schema of a file and the SchemaElements of a Schema.  The
+// columns are matched by name, groups are ignored.
+func CheckSchemaElements(before, after []*sh.SchemaElement) []SchemaChange {
+	leaves := map[string]*sh.SchemaElement{}
+	for _, se := range after {
+		if se.Type != nil {
+			leaves[se.Name] = se
+		}
+	}
+	var changes []SchemaChange
+	for _, o := range before {
+		if o.Type == nil {
+			continue
+		}
+		change := SchemaChange{Column: o.Name, Old: describeElement(o)}
+		n := leaves[o.Name]
+		if n == nil {
+			if o.GetRepetitionType() == sh.FieldRepetitionType_REQUIRED {
+				change.Kind = RequiredRemoved
+				changes = append(changes, change)
+			}
+			continue
+		}
+		change.New = describeElement(n)
+		or, nr := o.GetRepetitionType(), n.GetRepetitionType()
+		switch {
+		case or == nr:
+		case or == sh.FieldRepetitionType_REQUIRED && nr == sh.FieldRepetitionType_OPTIONAL:
+			change.Kind = RequiredToOptional
+			changes = append(changes, change)
+		case or == sh.FieldRepetitionType_REPEATED || nr == sh.FieldRepetitionType_REPEATED:
+			change.Kind = RepetitionChanged
+			changes = append(changes, change)
+		}
+		oc, nc := elementColumn(o, false), elementColumn(n, false)
+		// promotable compares the INTEGER annotations and plain integers,
+		// a DATE or a DECIMAL is another logical type
+		oint := oc.Int != nil || annotation(o) == ""
+		nint := nc.Int != nil || annotation(n) == ""
+		switch {
+		case !promotable(nc, oc):
+			change.Kind = TypeNarrowed
+			changes = append(changes, change)
+		case *o.Type == *n.Type && !(oint && nint) && annotation(o) != annotation(n):
+			change.Kind = LogicalTypeChanged
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// describeElement describes the repetition, type and annotation of a
+// leaf column, such as "optional BYTE_ARRAY UTF8".
+func describeElement(se *sh.SchemaElement) string {
+	s := strings.ToLower(se.GetRepetitionType().String()) + " " + se.Type.String()
+	if *se.Type == sh.Type_FIXED_LEN_BYTE_ARRAY {
+		s += fmt.Sprintf("(%d)", se.GetTypeLength())
+	}
+	if a := annotation(se); a != "" {
+		s += " " + a
+	}
+	return s
+}
+
+// annotation returns the name of the converted or logical type of a leaf
+// column, empty if it has none.
+func annotation(se *sh.SchemaElement) string {
+	lt := se.LogicalType
+	var s string
+	switch {
+	case se.IsSetConvertedType():
+		s = se.ConvertedType.String()
+	case lt == nil:
+		return ""
+	case lt.IsSetSTRING():
+		s = "UTF8"
+	case lt.IsSetENUM():
+		s = "ENUM"
+	case lt.IsSetINTEGER():
+		s = intConvertedTypes[*lt.INTEGER].String()
+	case lt.IsSetDECIMAL():
+		s = "DECIMAL"
+	case lt.IsSetDATE():
+		s = "DATE"
+	case lt.IsSetTIME():
+		s = "TIME"
+	case lt.IsSetTIMESTAMP():
+		s = "TIMESTAMP"
+	case lt.IsSetJSON():
+		s = "JSON"
+	case lt.IsSetBSON():
+		s = "BSON"
+	case lt.IsSetUUID():
+		s = "UUID"
+	case lt.IsSetMAP():
+		s = "MAP"
+	case lt.IsSetLIST():
+		s = "LIST"
+	}
+	if s == "DECIMAL" {
+		s += fmt.Sprintf("(%d,%d)", se.GetPrecision(), se.GetScale())
+	}
+	return s
+}
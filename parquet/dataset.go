@@ -0,0 +1,384 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// DatasetReader reads the records of the parquet files under a root
+// directory as one dataset, such as the ones written by a
+// PartitionedWriter.  The key=value directories of the files are the
+// values of partition columns that follow the columns of the files, and
+// the schemas of the files are merged by MergeFooters.  Files and
+// directories whose names start with . or _ are skipped.
+type DatasetReader struct {
+	root       string
+	files      []datasetFile
+	schema     *Schema
+	fileSchema *Schema // schema of the files, without the partitions
+	partitions []SchemaColumn
+	selected   []string
+	filter     Predicate
+	bound      filter
+	batchSize  int
+	next       int // next file to read
+	file       *os.File
+	reader     *ParquetReader
+	// workers decode the row groups in parallel when there are more than
+	// one, see DatasetReaderWorkers.
+	workers   int
+	unordered bool
+	memory    int64
+	parallel  *parallelReader
+	closed    bool
+}
+
+var errDatasetClosed = errors.New("the dataset reader is closed")
+
+// datasetFile is a file of a dataset and the values of its partitions.
+type datasetFile struct {
+	path   string
+	footer *sh.FileMetaData
+	values map[string]interface{}
+}
+
+// DatasetReaderColumns only reads the given columns of the files and
+// partitions, the records only have these fields.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderColumns(names ...string) func(*DatasetReader) {
+	return func(p *DatasetReader) { p.selected = names }
+}
+
+// DatasetReaderFilter only returns the records that match pred.  The files
+// whose partitions cannot match it are never opened, the row groups of the
+// other files are skipped as by ParquetReaderFilter.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderFilter(pred Predicate) func(*DatasetReader) {
+	return func(p *DatasetReader) { p.filter = pred }
+}
+
+// DatasetReaderBatchSize sets the number of rows ReadBatch returns.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderBatchSize(n int) func(*DatasetReader) {
+	return func(p *DatasetReader) { p.batchSize = n }
+}
+
+// DatasetReaderPartitions sets the types of partition columns, the values
+// of the other ones are longs if they all are integers, strings if not.
+// A partition column is optional if a file is in the default partition.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderPartitions(cols ...SchemaColumn) func(*DatasetReader) {
+	return func(p *DatasetReader) { p.partitions = cols }
+}
+
+// NewDatasetReader finds the parquet files under root, reads their footers
+// and prepares to read their records in the order of their paths.  Root
+// may also be a single parquet file, which then has no partitions.
+func NewDatasetReader(root string, opts ...func(*DatasetReader)) (*DatasetReader, error) {
+	p := &DatasetReader{root: root}
+	for _, opt := range opts {
+		opt(p)
+	}
+	var paths []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && strings.HasSuffix(name, ".parquet") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	keys, values, err := p.partitionValues(paths)
+	if err != nil {
+		return nil, err
+	}
+	footers := make([]*sh.FileMetaData, len(paths))
+	for i, path := range paths {
+		if footers[i], err = readFooter(path); err != nil {
+			return nil, err
+		}
+	}
+	merged, err := MergeFooters(footers...)
+	if err != nil {
+		return nil, err
+	}
+	p.fileSchema = merged.Schema
+	if err := p.setSchema(keys, values); err != nil {
+		return nil, err
+	}
+	for i, path := range paths {
+		f := datasetFile{path: path, footer: footers[i], values: map[string]interface{}{}}
+		for j, key := range keys {
+			f.values[key] = nil
+			if s, ok := unescapePartitionValue(values[i][j]); ok {
+				f.values[key] = p.schema.Field(key).convert(s)
+			}
+		}
+		p.files = append(p.files, f)
+	}
+
+	if p.selected == nil {
+		for i := range p.schema.Fields {
+			p.selected = append(p.selected, p.schema.Fields[i].name)
+		}
+	}
+	for _, name := range p.selected {
+		if p.schema.Field(name) == nil {
+			return nil, fmt.Errorf("column %s is not in the dataset", name)
+		}
+	}
+	if p.filter != nil {
+		if p.bound, err = p.filter.bind(p.schema); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// partitionValues returns the partition keys of the files and the
+// escaped values of each file, all files must have the same keys.
+func (p *DatasetReader) partitionValues(paths []string) (keys []string, values [][]string, err error) {
+	for i, path := range paths {
+		rel, err := filepath.Rel(p.root, filepath.Dir(path))
+		if err != nil {
+			return nil, nil, err
+		}
+		var fileKeys, fileValues []string
+		for _, dir := range strings.Split(filepath.ToSlash(rel), "/") {
+			if kv := strings.SplitN(dir, "=", 2); len(kv) == 2 {
+				fileKeys = append(fileKeys, kv[0])
+				fileValues = append(fileValues, kv[1])
+			}
+		}
+		if i == 0 {
+			keys = fileKeys
+		} else if strings.Join(fileKeys, "/") != strings.Join(keys, "/") {
+			return nil, nil, fmt.Errorf("file %s is partitioned by %v, not by %v", path, fileKeys, keys)
+		}
+		values = append(values, fileValues)
+	}
+	return keys, values, nil
+}
+
+// setSchema sets the schema of the dataset, the columns of the files
+// followed by the partition columns with the given keys.
+func (p *DatasetReader) setSchema(keys []string, values [][]string) error {
+	cols := make([]SchemaColumn, 0, len(p.fileSchema.Fields)+len(keys))
+	for i := range p.fileSchema.Fields {
+		cols = append(cols, p.fileSchema.Fields[i].column())
+	}
+	for j, key := range keys {
+		if p.fileSchema.Field(key) != nil {
+			return fmt.Errorf("partition column %s is also a column of the files", key)
+		}
+		c := SchemaColumn{Name: key, Type: sh.Type_INT64}
+		declared := false
+		for _, pc := range p.partitions {
+			if pc.Name == key {
+				c, declared = pc, true
+			}
+		}
+		for i := range values {
+			s, ok := unescapePartitionValue(values[i][j])
+			if !ok {
+				c.Optional = true
+			} else if _, err := strconv.ParseInt(s, 10, 64); err != nil && !declared {
+				c.Type = sh.Type_BYTE_ARRAY
+			}
+		}
+		cols = append(cols, c)
+	}
+	for _, pc := range p.partitions {
+		if !containsString(keys, pc.Name) {
+			return fmt.Errorf("partition column %s is not in the directories", pc.Name)
+		}
+	}
+	var err error
+	p.schema, err = NewSchemaFromColumns(cols, p.fileSchema.CompressionCodec)
+	return err
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readFooter reads the footer of the file at path.
+func readFooter(path string) (*sh.FileMetaData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	footer, err := ReadMetaData(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return footer, nil
+}
+
+// Schema returns the schema of the records, the merged schema of the
+// files followed by the partition columns.
+func (p *DatasetReader) Schema() *Schema {
+	return p.schema
+}
+
+// Columns returns the names of the columns of the records, in the order
+// of the columns of a Batch.
+func (p *DatasetReader) Columns() []string {
+	return p.selected
+}
+
+// Files returns the paths of the files of the dataset.
+func (p *DatasetReader) Files() []string {
+	paths := make([]string, len(p.files))
+	for i, f := range p.files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// Read sets the fields of the next record in record, it returns io.EOF
+// once all records have been read.
+func (p *DatasetReader) Read(record *map[string]interface{}) error {
+	for {
+		if err := p.nextReader(); err != nil {
+			return err
+		}
+		err := p.reader.Read(record)
+		if err != io.EOF {
+			return err
+		}
+		if err := p.doneReader(); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadBatch replaces the rows of batch with the next rows of the dataset,
+// as ParquetReader.ReadBatch does.  A batch holds the rows of one file,
+// so the last batch of every file may have less rows than the batch size,
+// and with more than one worker those of one row group.
+func (p *DatasetReader) ReadBatch(batch *Batch) error {
+	for {
+		if err := p.nextReader(); err != nil {
+			return err
+		}
+		err := p.reader.ReadBatch(batch)
+		if err != io.EOF {
+			return err
+		}
+		if err := p.doneReader(); err != nil {
+			return err
+		}
+	}
+}
+
+// nextReader sets the reader of the next file whose partitions may match
+// the filter, or of the next decoded row group with more than one worker,
+// once all records of the current one have been read.
+func (p *DatasetReader) nextReader() error {
+	if p.closed {
+		return errDatasetClosed
+	}
+	if p.workers > 1 {
+		return p.nextRowGroup()
+	}
+	for p.reader == nil {
+		if p.next == len(p.files) {
+			return io.EOF
+		}
+		df := p.files[p.next]
+		p.next++
+		if p.skip(&df) {
+			continue
+		}
+		f, err := os.Open(df.path)
+		if err != nil {
+			return err
+		}
+		r, err := newParquetReader(f, df.footer, p.readerOptions(&df)...)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %s", df.path, err)
+		}
+		p.file, p.reader = f, r
+	}
+	return nil
+}
+
+// skip reports whether the partitions of the file cannot match the filter.
+func (p *DatasetReader) skip(df *datasetFile) bool {
+	if p.bound == nil {
+		return false
+	}
+	match, ok := p.bound.decide(df.values)
+	return ok && !match
+}
+
+// readerOptions returns the options of the readers of the file.
+func (p *DatasetReader) readerOptions(df *datasetFile) []func(*ParquetReader) {
+	opts := []func(*ParquetReader){
+		ParquetReaderSchema(p.schema),
+		ParquetReaderColumns(p.selected...),
+		ParquetReaderBatchSize(p.batchSize),
+		func(r *ParquetReader) { r.constants = df.values },
+	}
+	if p.filter != nil {
+		opts = append(opts, ParquetReaderFilter(p.filter))
+	}
+	return opts
+}
+
+// doneReader closes the file of the reader whose records have all been
+// read, or frees the memory of its row group.
+func (p *DatasetReader) doneReader() error {
+	if p.workers > 1 {
+		p.parallel.release(p.reader)
+		p.reader = nil
+		return nil
+	}
+	err := p.file.Close()
+	p.file, p.reader = nil, nil
+	return err
+}
+
+// Close closes the file being read, and stops the workers.  Read and
+// ReadBatch return an error once the reader is closed.
+func (p *DatasetReader) Close() error {
+	p.closed = true
+	if p.parallel != nil {
+		p.parallel.stop()
+		p.parallel = nil
+		return nil
+	}
+	if p.file == nil {
+		return nil
+	}
+	return p.doneReader()
+}
@@ -0,0 +1,95 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/houkx/parquet-go/parquet/internal/rle"
+	sh "github.com/houkx/parquet-go/parquet/schema"
+	"github.com/valyala/bytebufferpool"
+)
+
+// writeDictionary writes the distinct values of a BYTE_ARRAY column as
+// a dictionary page and appends the RLE_DICTIONARY encoding of the
+// values, the bit width and the RLE encoded indexes, to buf.
+func (f *SchemaField) writeDictionary(w io.Writer, meta *Metadata, values *Values, buf *bytebufferpool.ByteBuffer) error {
+	index := make(map[string]uint32)
+	ids := make([]uint32, len(values.strs))
+	page := GetBuffer()
+	defer PutBuffer(page)
+	var size [4]byte
+	for i, s := range values.strs {
+		id, ok := index[s]
+		if !ok {
+			id = uint32(len(index))
+			index[s] = id
+			binary.LittleEndian.PutUint32(size[:], uint32(len(s)))
+			page.Write(size[:])
+			page.WriteString(s)
+		}
+		ids[i] = id
+	}
+
+	l, cl, data, done := compress(f.Codec, page.Bytes())
+	defer done()
+	if err := meta.writeDictionaryPageHeader(w, f.Paths, l, cl, len(index), f.Codec); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	var width int
+	if len(index) > 1 {
+		width = bits.Len32(uint32(len(index) - 1))
+	}
+	enc, err := rle.New(int32(width), len(ids))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		enc.Write(id)
+	}
+	buf.WriteByte(byte(width))
+	buf.B = enc.Encode(buf.B)
+	return nil
+}
+
+// readDictionary decodes the PLAIN encoded values of a dictionary page.
+func (c *ColumnReader) readDictionary(ph *sh.PageHeader, data []byte) error {
+	c.dict = *c.field.makeValues(0)
+	c.dict.defs = nil
+	c.dictLen = int(ph.DictionaryPageHeader.NumValues)
+	if ph.DictionaryPageHeader.Encoding != sh.Encoding_PLAIN && ph.DictionaryPageHeader.Encoding != sh.Encoding_PLAIN_DICTIONARY {
+		return fmt.Errorf("unsupported dictionary encoding %s", ph.DictionaryPageHeader.Encoding)
+	}
+	return decodePlain(c.field.fieldType, c.field.typeLength, data, c.dictLen, &c.dict)
+}
+
+// decodeDictionary appends the n values of a page whose values are
+// indexes into the dictionary of the column chunk.
+func (c *ColumnReader) decodeDictionary(data []byte, n int, values *Values) error {
+	if len(data) < 1 {
+		return io.ErrUnexpectedEOF
+	}
+	dec, err := rle.New(int32(data[0]), n)
+	if err != nil {
+		return err
+	}
+	ids, err := dec.Decode(data[1:])
+	if err != nil {
+		return err
+	}
+	if len(ids) < n {
+		return fmt.Errorf("page has %d dictionary indexes, expected %d", len(ids), n)
+	}
+	for _, id := range ids[:n] {
+		if int(id) >= c.dictLen {
+			return fmt.Errorf("dictionary index %d out of range, the dictionary has %d values", id, c.dictLen)
+		}
+		values.appendFrom(c.field.fieldType, &c.dict, int(id))
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/houkx/parquet-go/parquet/internal/delta"
+	"github.com/houkx/parquet-go/parquet/internal/rle"
+	sh "github.com/houkx/parquet-go/parquet/schema"
+	"github.com/valyala/bytebufferpool"
+)
+
+// SetEncoding makes the column write its values with the encoding enc
+// instead of PLAIN.  DELTA_BINARY_PACKED is for INT32 and INT64 columns,
+// DELTA_LENGTH_BYTE_ARRAY and DELTA_BYTE_ARRAY for strings and
+// BYTE_STREAM_SPLIT for FLOAT and DOUBLE columns and RLE for BOOLEAN
+// columns.  RLE_DICTIONARY writes a dictionary page of the distinct values
+// of BYTE_ARRAY columns first, it is the default of enum columns.  It must
+// be called before the schema is used by a writer.
+func (p *Schema) SetEncoding(name string, enc sh.Encoding) error {
+	for i := range p.Fields {
+		f := &p.Fields[i]
+		if f.name != name {
+			continue
+		}
+		if !supportsEncoding(f.fieldType, enc) {
+			return fmt.Errorf("column %s of type %s cannot be written with encoding %s", name, f.fieldType, enc)
+		}
+		p.Fields[i], p.PFields[i] = newSchemaField(f.column(), f.defaultValue, f.Codec, RequiredFieldEncoding(enc))
+		return nil
+	}
+	return fmt.Errorf("column %s is not in the schema", name)
+}
+
+func supportsEncoding(t sh.Type, enc sh.Encoding) bool {
+	switch enc {
+	case sh.Encoding_PLAIN:
+		return true
+	case sh.Encoding_DELTA_BINARY_PACKED:
+		return t == sh.Type_INT32 || t == sh.Type_INT64
+	case sh.Encoding_DELTA_LENGTH_BYTE_ARRAY, sh.Encoding_DELTA_BYTE_ARRAY, sh.Encoding_RLE_DICTIONARY:
+		return t == sh.Type_BYTE_ARRAY
+	case sh.Encoding_BYTE_STREAM_SPLIT:
+		return t == sh.Type_FLOAT || t == sh.Type_DOUBLE
+	case sh.Encoding_RLE:
+		return t == sh.Type_BOOLEAN
+	}
+	return false
+}
+
+// encode appends the values of the column to buf, with the encoding of
+// the column.
+func (f *SchemaField) encode(buf *bytebufferpool.ByteBuffer, values *Values) {
+	switch f.Encoding {
+	case sh.Encoding_DELTA_BINARY_PACKED:
+		if f.fieldType == sh.Type_INT32 {
+			buf.B = delta.AppendInt32(buf.B, values.i32s)
+		} else {
+			buf.B = delta.AppendInt64(buf.B, values.i64s)
+		}
+		return
+	case sh.Encoding_DELTA_LENGTH_BYTE_ARRAY:
+		buf.B = delta.AppendLengthByteArray(buf.B, values.strs)
+		return
+	case sh.Encoding_DELTA_BYTE_ARRAY:
+		buf.B = delta.AppendByteArray(buf.B, values.strs)
+		return
+	case sh.Encoding_BYTE_STREAM_SPLIT:
+		buf.B = appendByteStreamSplit(buf.B, f.fieldType, values)
+		return
+	case sh.Encoding_RLE:
+		enc, _ := rle.New(1, len(values.boos))
+		for _, b := range values.boos {
+			if b {
+				enc.Write(1)
+			} else {
+				enc.Write(0)
+			}
+		}
+		buf.Write(enc.Bytes())
+		return
+	}
+
+	order := binary.LittleEndian
+	switch f.fieldType {
+	case sh.Type_BYTE_ARRAY:
+		sizeBuf := f.intSizePool.Get().([]byte)
+		defer f.intSizePool.Put(sizeBuf)
+		for _, str := range values.strs {
+			order.PutUint32(sizeBuf, uint32(len(str)))
+			buf.Write(sizeBuf)
+			buf.WriteString(str)
+		}
+	case sh.Type_INT32:
+		WriteI32s(buf, order, values.i32s)
+	case sh.Type_FLOAT:
+		WriteF32s(buf, order, values.f32s)
+	case sh.Type_DOUBLE:
+		WriteF64s(buf, order, values.f64s)
+	case sh.Type_INT64:
+		WriteI64s(buf, order, values.i64s)
+	case sh.Type_BOOLEAN:
+		packed := make([]byte, (len(values.boos)+7)/8)
+		for i, b := range values.boos {
+			if b {
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+		buf.Write(packed)
+	case sh.Type_FIXED_LEN_BYTE_ARRAY, sh.Type_INT96:
+		for _, b := range values.bins {
+			buf.Write(b)
+		}
+	}
+}
+
+// decode appends n values of type t, encoded with enc, to values.  size
+// is the length of FIXED_LEN_BYTE_ARRAY values.
+func decode(t sh.Type, size int, enc sh.Encoding, data []byte, n int, values *Values) error {
+	var err error
+	switch enc {
+	case sh.Encoding_PLAIN:
+		return decodePlain(t, size, data, n, values)
+	case sh.Encoding_DELTA_BINARY_PACKED:
+		switch t {
+		case sh.Type_INT32:
+			var vs []int32
+			vs, _, err = delta.Int32s(data, n)
+			values.i32s = append(values.i32s, vs...)
+			return err
+		case sh.Type_INT64:
+			var vs []int64
+			vs, _, err = delta.Int64s(data, n)
+			values.i64s = append(values.i64s, vs...)
+			return err
+		}
+	case sh.Encoding_DELTA_LENGTH_BYTE_ARRAY, sh.Encoding_DELTA_BYTE_ARRAY:
+		if t == sh.Type_BYTE_ARRAY {
+			var vs []string
+			if enc == sh.Encoding_DELTA_BYTE_ARRAY {
+				vs, _, err = delta.ByteArrays(data, n)
+			} else {
+				vs, _, err = delta.LengthByteArrays(data, n)
+			}
+			values.strs = append(values.strs, vs...)
+			return err
+		}
+	case sh.Encoding_BYTE_STREAM_SPLIT:
+		if t == sh.Type_FLOAT || t == sh.Type_DOUBLE {
+			return decodeByteStreamSplit(t, data, n, values)
+		}
+	case sh.Encoding_RLE:
+		if t == sh.Type_BOOLEAN {
+			bools, err := decodeRLEBools(data, n)
+			values.boos = append(values.boos, bools...)
+			return err
+		}
+	}
+	return fmt.Errorf("unsupported encoding %s for %s", enc, t)
+}
+
+// appendByteStreamSplit appends the BYTE_STREAM_SPLIT encoding of the
+// FLOAT or DOUBLE values, the k-th bytes of all values come before the
+// k+1-th bytes.
+func appendByteStreamSplit(dst []byte, t sh.Type, values *Values) []byte {
+	var n, size int
+	if t == sh.Type_FLOAT {
+		n, size = len(values.f32s), 4
+	} else {
+		n, size = len(values.f64s), 8
+	}
+	start := len(dst)
+	dst = append(dst, make([]byte, n*size)...)
+	out := dst[start:]
+	for i := 0; i < n; i++ {
+		var bits uint64
+		if t == sh.Type_FLOAT {
+			bits = uint64(math.Float32bits(values.f32s[i]))
+		} else {
+			bits = math.Float64bits(values.f64s[i])
+		}
+		for k := 0; k < size; k++ {
+			out[k*n+i] = byte(bits >> (8 * uint(k)))
+		}
+	}
+	return dst
+}
+
+// decodeByteStreamSplit appends n BYTE_STREAM_SPLIT encoded values.
+func decodeByteStreamSplit(t sh.Type, data []byte, n int, values *Values) error {
+	size := 8
+	if t == sh.Type_FLOAT {
+		size = 4
+	}
+	if len(data) < n*size {
+		return io.ErrUnexpectedEOF
+	}
+	for i := 0; i < n; i++ {
+		var bits uint64
+		for k := 0; k < size; k++ {
+			bits |= uint64(data[k*n+i]) << (8 * uint(k))
+		}
+		if t == sh.Type_FLOAT {
+			values.f32s = append(values.f32s, math.Float32frombits(uint32(bits)))
+		} else {
+			values.f64s = append(values.f64s, math.Float64frombits(bits))
+		}
+	}
+	return nil
+}
+
+// decodeRLEBools decodes n RLE encoded booleans, which are prefixed with
+// the length of their runs.
+func decodeRLEBools(data []byte, n int) ([]bool, error) {
+	dec, _ := rle.New(1, 0)
+	vals, _, err := dec.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) < n {
+		return nil, fmt.Errorf("page has %d booleans, expected %d", len(vals), n)
+	}
+	out := make([]bool, n)
+	for i := range out {
+		out[i] = vals[i] == 1
+	}
+	return out, nil
+}
+
+// plainValues converts the values of a page encoded with enc to PLAIN,
+// for the readers that only read PLAIN values, such as GetBools.
+func plainValues(enc sh.Encoding, data []byte, n int) ([]byte, error) {
+	if enc != sh.Encoding_RLE {
+		return data, nil
+	}
+	bools, err := decodeRLEBools(data, n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, (n+7)/8)
+	for i, b := range bools {
+		if b {
+			out[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return out, nil
+}
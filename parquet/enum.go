@@ -0,0 +1,95 @@
+package parquet
+
+import (
+	"fmt"
+)
+
+// EnumPolicy is what writers do with a value of an enum column that is
+// not one of its symbols.
+type EnumPolicy int
+
+const (
+	// EnumReject makes Write and WriteBatch return an error.
+	EnumReject EnumPolicy = iota
+	// EnumDefault writes the default value of the column instead.
+	EnumDefault
+	// EnumKeep writes the value as it is.
+	EnumKeep
+)
+
+// enumType holds the symbols of an enum column.
+type enumType struct {
+	symbols []string
+	set     map[string]bool
+	policy  EnumPolicy
+}
+
+func newEnumType(symbols []string, policy EnumPolicy) *enumType {
+	set := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		set[s] = true
+	}
+	return &enumType{symbols: symbols, set: set, policy: policy}
+}
+
+// known reports whether s is one of the symbols, any value is when the
+// symbols are unknown, as for the enum columns of a file.
+func (e *enumType) known(s string) bool {
+	return len(e.symbols) == 0 || e.set[s]
+}
+
+// SetEnumPolicy sets what writers do with values of the enum column that
+// are not among its symbols, EnumReject unless it is called.  It must be
+// called before the schema is used by a writer.
+func (p *Schema) SetEnumPolicy(name string, policy EnumPolicy) error {
+	for i := range p.Fields {
+		f := &p.Fields[i]
+		if f.name != name {
+			continue
+		}
+		if f.enum == nil {
+			return fmt.Errorf("column %s is not an enum", name)
+		}
+		c := f.column()
+		c.UnknownSymbols = policy
+		p.Fields[i], p.PFields[i] = newSchemaField(c, f.defaultValue, f.Codec, RequiredFieldEncoding(f.Encoding))
+		return nil
+	}
+	return fmt.Errorf("column %s is not in the schema", name)
+}
+
+// Symbols returns the symbols of an enum column, nil for other columns.
+func (f *SchemaField) Symbols() []string {
+	if f.enum == nil {
+		return nil
+	}
+	return f.enum.symbols
+}
+
+// checkEnums returns an error if a value of record is not a symbol of
+// its enum column whose policy is EnumReject.
+func (p *Schema) checkEnums(record *map[string]interface{}) error {
+	for i := range p.Fields {
+		f := &p.Fields[i]
+		if f.enum == nil || f.enum.policy != EnumReject {
+			continue
+		}
+		v := (*record)[f.name]
+		if v == nil {
+			continue
+		}
+		if s := f.convert(v).(string); !f.enum.known(s) {
+			return fmt.Errorf("column %s: %q is not a symbol of the enum", f.name, s)
+		}
+	}
+	return nil
+}
+
+// enumValue returns s, or the default value of the column if s is not
+// one of its symbols and the policy is EnumDefault.
+func (f *SchemaField) enumValue(s string) string {
+	if f.enum.policy == EnumDefault && !f.enum.known(s) {
+		return f.defaultValue.(string)
+	}
+	return s
+}
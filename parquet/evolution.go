@@ -0,0 +1,219 @@
+package parquet
+
+import (
+	"fmt"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// resolvedColumn fills the values of a field of a reader schema with the
+// values of the column of the file it resolves to.
+type resolvedColumn struct {
+	c   *ColumnReader // the field of the reader schema
+	src *ColumnReader // the column of the file, nil if it has none
+	// same is set when the values of the file can be used as they are.
+	same bool
+	// value is the value of every row when the file has no column.
+	value interface{}
+	row   map[string]interface{}
+}
+
+// resolve sets the values of the rows of the decoded row group.  A field
+// the file does not have is null if it is optional, its default value if
+// it is required, as are the nulls of an optional column of the file,
+// unless the reader has a constant value for it.
+func (r *resolvedColumn) resolve(rows int) {
+	c := r.c
+	if r.same {
+		c.values, c.n = r.src.values, r.src.n
+		return
+	}
+	c.field.reset(&c.values)
+	for i := 0; i < rows; i++ {
+		v := r.value
+		if r.src != nil {
+			v = promote(r.src.field.value(&r.src.values, i), c.field)
+		}
+		r.row[c.field.name] = v
+		c.field.append(&c.values, &r.row)
+	}
+	c.n = rows
+}
+
+// resolveSchema resolves the fields read by p with its reader schema to
+// the columns of the file, only these columns are decoded.  It returns an
+// error for a required field without a default the file does not have.
+func (p *ParquetReader) resolveSchema() error {
+	sources := map[*SchemaField]*ColumnReader{}
+	p.statNames = map[string]string{}
+	for _, c := range p.readers {
+		f, err := resolveField(p.file, c.field)
+		if err != nil {
+			return err
+		}
+		value, constant := p.constants[c.field.name]
+		if f == nil && !c.field.optional && !c.field.hasDefault && !constant {
+			return fmt.Errorf("field %s is required and has no default, the file has no column for it", c.field.name)
+		}
+		r := &resolvedColumn{c: c, value: value, row: map[string]interface{}{}}
+		if f != nil {
+			if r.src = sources[f]; r.src == nil {
+				r.src = newColumnReader(f)
+				sources[f] = r.src
+				p.sources = append(p.sources, r.src)
+			}
+			r.same = f.fieldType == c.field.fieldType && f.optional == c.field.optional
+			if f.fieldType == c.field.fieldType && f.binary == c.field.binary && sameIntType(f.intType, c.field.intType) {
+				p.statNames[c.field.name] = f.name
+			}
+		}
+		p.resolved = append(p.resolved, r)
+	}
+	return nil
+}
+
+// resolveField returns the column of file read as the field f of a reader
+// schema, the column with its name or else with one of its aliases, nil
+// if there is none.  It returns an error if the values of the column
+// cannot be promoted to the type of f.
+func resolveField(file *Schema, f *SchemaField) (*SchemaField, error) {
+	src := file.Field(f.name)
+	for _, a := range f.aliases {
+		if src != nil {
+			break
+		}
+		src = file.Field(a)
+	}
+	if src == nil {
+		return nil, nil
+	}
+	if !promotable(src.column(), f.column()) {
+		return nil, fmt.Errorf("column %s of type %s cannot be read as %s", src.name, typeName(src.column()), typeName(f.column()))
+	}
+	return src, nil
+}
+
+// promotable reports whether the values of a column of type from can be
+// read as values of type to.  Besides the same type, integers can be read
+// as wider integers and as floating point numbers, floats as doubles, and
+// strings, bytes and enums as one another, as in avro.
+func promotable(from, to SchemaColumn) bool {
+	fi, fok := intRange(from)
+	ti, tok := intRange(to)
+	switch {
+	case fok && tok:
+		if fi.IsSigned == ti.IsSigned {
+			return ti.BitWidth >= fi.BitWidth
+		}
+		return !fi.IsSigned && ti.BitWidth > fi.BitWidth
+	case fok:
+		return to.Type == sh.Type_FLOAT || to.Type == sh.Type_DOUBLE
+	case tok:
+		return false
+	}
+	switch from.Type {
+	case sh.Type_FLOAT:
+		return to.Type == sh.Type_FLOAT || to.Type == sh.Type_DOUBLE
+	case sh.Type_FIXED_LEN_BYTE_ARRAY:
+		return to.Type == from.Type && to.Length == from.Length
+	}
+	return to.Type == from.Type
+}
+
+// intRange returns the bit width and sign of the values of an integer
+// column, ok is false for other columns.
+func intRange(c SchemaColumn) (it sh.IntType, ok bool) {
+	if c.Int != nil {
+		return *c.Int, true
+	}
+	switch c.Type {
+	case sh.Type_INT32:
+		return sh.IntType{BitWidth: 32, IsSigned: true}, true
+	case sh.Type_INT64:
+		return sh.IntType{BitWidth: 64, IsSigned: true}, true
+	}
+	return it, false
+}
+
+func sameIntType(a, b *sh.IntType) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// typeName returns the name of the type of the values of c, as used in
+// avro schemas where there is one.
+func typeName(c SchemaColumn) string {
+	if c.Int != nil {
+		if c.Int.IsSigned {
+			return fmt.Sprintf("int%d", c.Int.BitWidth)
+		}
+		return fmt.Sprintf("uint%d", c.Int.BitWidth)
+	}
+	switch c.Type {
+	case sh.Type_BYTE_ARRAY:
+		switch {
+		case c.Binary:
+			return "bytes"
+		case c.Enum:
+			return "enum"
+		}
+		return "string"
+	case sh.Type_INT32:
+		return "int"
+	case sh.Type_INT64:
+		return "long"
+	case sh.Type_FLOAT:
+		return "float"
+	case sh.Type_DOUBLE:
+		return "double"
+	case sh.Type_BOOLEAN:
+		return "boolean"
+	case sh.Type_FIXED_LEN_BYTE_ARRAY:
+		return fmt.Sprintf("fixed(%d)", c.Length)
+	case sh.Type_INT96:
+		return "int96"
+	}
+	return c.Type.String()
+}
+
+// promote converts a value read from a file to the type of the field f
+// of a reader schema, the append of f converts it to the values of f.
+func promote(v interface{}, f *SchemaField) interface{} {
+	if v == nil || f.intType != nil {
+		return v
+	}
+	switch f.fieldType {
+	case sh.Type_INT32:
+		return int32(intBits(v))
+	case sh.Type_INT64:
+		return intBits(v)
+	case sh.Type_FLOAT:
+		return float32(toFloat64(v))
+	case sh.Type_DOUBLE:
+		return toFloat64(v)
+	case sh.Type_BYTE_ARRAY:
+		switch x := v.(type) {
+		case []byte:
+			if !f.binary {
+				return string(x)
+			}
+		case string:
+			if f.binary {
+				return []byte(x)
+			}
+		}
+	}
+	return v
+}
+
+// toFloat64 converts a float or an integer value returned by intValue.
+func toFloat64(v interface{}) float64 {
+	switch x := v.(type) {
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	case uint64:
+		return float64(x)
+	}
+	return float64(intBits(v))
+}
@@ -15,6 +15,7 @@ type int32stats struct {
 func newInt32stats() *int32stats {
 	return &int32stats{
 		min: int32(math.MaxInt32),
+		max: int32(math.MinInt32),
 	}
 }
 
@@ -120,6 +121,7 @@ type int64stats struct {
 func newInt64stats() *int64stats {
 	return &int64stats{
 		min: int64(math.MaxInt64),
+		max: int64(math.MinInt64),
 	}
 }
 
@@ -283,6 +285,7 @@ type float32stats struct {
 func newFloat32stats() *float32stats {
 	return &float32stats{
 		min: float32(math.MaxFloat32),
+		max: -float32(math.MaxFloat32),
 	}
 }
 
@@ -325,6 +328,7 @@ type float64stats struct {
 func newFloat64stats() *float64stats {
 	return &float64stats{
 		min: float64(math.MaxFloat64),
+		max: -float64(math.MaxFloat64),
 	}
 }
 
@@ -497,6 +501,48 @@ func (f *uint32stats) Max() []byte {
 	return f.bytes(f.max)
 }
 
+type uint64stats struct {
+	min uint64
+	max uint64
+}
+
+func newUint64stats() *uint64stats {
+	return &uint64stats{
+		min: uint64(math.MaxUint64),
+	}
+}
+
+func (i *uint64stats) add(val uint64) {
+	if val < i.min {
+		i.min = val
+	}
+	if val > i.max {
+		i.max = val
+	}
+}
+
+func (f *uint64stats) bytes(val uint64) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, val)
+	return buf.Bytes()
+}
+
+func (f *uint64stats) NullCount() *int64 {
+	return nil
+}
+
+func (f *uint64stats) DistinctCount() *int64 {
+	return nil
+}
+
+func (f *uint64stats) Min() []byte {
+	return f.bytes(f.min)
+}
+
+func (f *uint64stats) Max() []byte {
+	return f.bytes(f.max)
+}
+
 type uint64optionalStats struct {
 	min     uint64
 	max     uint64
@@ -561,9 +607,9 @@ func (f *uint64optionalStats) Max() []byte {
 }
 
 type stringStats struct {
-	vals []string
-	min  []byte
-	max  []byte
+	n   int
+	min string
+	max string
 }
 
 func newStringStats() *stringStats {
@@ -571,7 +617,13 @@ func newStringStats() *stringStats {
 }
 
 func (s *stringStats) add(val string) {
-	s.vals = append(s.vals, val)
+	if s.n == 0 || val < s.min {
+		s.min = val
+	}
+	if s.n == 0 || val > s.max {
+		s.max = val
+	}
+	s.n++
 }
 
 func (s *stringStats) NullCount() *int64 {
@@ -583,29 +635,17 @@ func (s *stringStats) DistinctCount() *int64 {
 }
 
 func (s *stringStats) Min() []byte {
-	if s.min == nil {
-		s.minMax()
+	if s.n == 0 {
+		return nil
 	}
-	return s.min
+	return []byte(s.min)
 }
 
 func (s *stringStats) Max() []byte {
-	if s.max == nil {
-		s.minMax()
-	}
-	return s.max
-}
-
-func (s *stringStats) minMax() {
-	if len(s.vals) == 0 {
-		return
+	if s.n == 0 {
+		return nil
 	}
-
-	tmp := make([]string, len(s.vals))
-	copy(tmp, s.vals)
-	sort.Strings(tmp)
-	s.min = []byte(tmp[0])
-	s.max = []byte(tmp[len(tmp)-1])
+	return []byte(s.max)
 }
 
 type boolStats struct{}
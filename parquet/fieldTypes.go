@@ -8,10 +8,7 @@ func Int32Type(se *sh.SchemaElement) {
 }
 
 func Uint32Type(se *sh.SchemaElement) {
-	t := sh.Type_INT32
-	se.Type = &t
-	ct := sh.ConvertedType_UINT_32
-	se.ConvertedType = &ct
+	IntegerType(32, false)(se)
 }
 
 func Int64Type(se *sh.SchemaElement) {
@@ -20,10 +17,7 @@ func Int64Type(se *sh.SchemaElement) {
 }
 
 func Uint64Type(se *sh.SchemaElement) {
-	t := sh.Type_INT64
-	se.Type = &t
-	ct := sh.ConvertedType_UINT_64
-	se.ConvertedType = &ct
+	IntegerType(64, false)(se)
 }
 
 func Float32Type(se *sh.SchemaElement) {
@@ -44,4 +38,38 @@ func BoolType(se *sh.SchemaElement) {
 func StringType(se *sh.SchemaElement) {
 	t := sh.Type_BYTE_ARRAY
 	se.Type = &t
+	ct := sh.ConvertedType_UTF8
+	se.ConvertedType = &ct
+	se.LogicalType = &sh.LogicalType{STRING: &sh.StringType{}}
+}
+
+// EnumType is a column of the symbols of an enum.
+func EnumType(se *sh.SchemaElement) {
+	t := sh.Type_BYTE_ARRAY
+	se.Type = &t
+	ct := sh.ConvertedType_ENUM
+	se.ConvertedType = &ct
+	se.LogicalType = &sh.LogicalType{ENUM: &sh.EnumType{}}
+}
+
+// BinaryType is a column of raw bytes, it has no STRING annotation.
+func BinaryType(se *sh.SchemaElement) {
+	t := sh.Type_BYTE_ARRAY
+	se.Type = &t
+}
+
+// FixedLenByteArrayType returns the type of a column of values that
+// are size bytes long.
+func FixedLenByteArrayType(size int) FieldFunc {
+	return func(se *sh.SchemaElement) {
+		t := sh.Type_FIXED_LEN_BYTE_ARRAY
+		se.Type = &t
+		l := int32(size)
+		se.TypeLength = &l
+	}
+}
+
+func Int96Type(se *sh.SchemaElement) {
+	t := sh.Type_INT96
+	se.Type = &t
 }
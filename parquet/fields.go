@@ -2,6 +2,7 @@ package parquet
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"github.com/golang/snappy"
 	"github.com/houkx/parquet-go/parquet/internal/fields"
@@ -14,8 +15,9 @@ import (
 
 // RequiredField writes the raw data for required columns
 type RequiredField struct {
-	Paths []string
-	Codec sch.CompressionCodec
+	Paths    []string
+	Codec    sch.CompressionCodec
+	Encoding sch.Encoding
 }
 
 // NewRequiredField creates a required field.
@@ -42,10 +44,18 @@ func RequiredFieldUncompressed(r *RequiredField) {
 	r.Codec = sch.CompressionCodec_UNCOMPRESSED
 }
 
+// RequiredFieldEncoding sets the encoding of the values of a column,
+// the data passed to DoWrite must be encoded with it.
+// It is an optional arg to NewRequiredField
+func RequiredFieldEncoding(enc sch.Encoding) func(*RequiredField) {
+	return func(r *RequiredField) { r.Encoding = enc }
+}
+
 // DoWrite writes the actual raw data.
 func (f *RequiredField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count int, stats Stats) error {
-	l, cl, vals := compress(f.Codec, vals)
-	if err := meta.WritePageHeader(w, f.Paths, l, cl, count, count, 0, 0, f.Codec, stats); err != nil {
+	l, cl, vals, done := compress(f.Codec, vals)
+	defer done()
+	if err := meta.writePageHeader(w, f.Paths, f.Encoding, l, cl, count, count, 0, 0, f.Codec, stats); err != nil {
 		return err
 	}
 
@@ -63,6 +73,9 @@ func (f *RequiredField) DoRead(r io.ReadSeeker, pg Page) (io.Reader, []int, erro
 		if err != nil {
 			return nil, nil, err
 		}
+		if ph.Type != sch.PageType_DATA_PAGE {
+			return nil, nil, fmt.Errorf("column %s: unsupported page type %s", f.Name(), ph.Type)
+		}
 
 		sizes = append(sizes, int(ph.DataPageHeader.NumValues))
 
@@ -70,6 +83,10 @@ func (f *RequiredField) DoRead(r io.ReadSeeker, pg Page) (io.Reader, []int, erro
 		if err != nil {
 			return nil, nil, err
 		}
+		data, err = plainValues(ph.DataPageHeader.Encoding, data, int(ph.DataPageHeader.NumValues))
+		if err != nil {
+			return nil, nil, err
+		}
 
 		out = append(out, data...)
 		nRead += int(ph.DataPageHeader.NumValues)
@@ -102,6 +119,7 @@ type OptionalField struct {
 	pth            []string
 	MaxLevels      MaxLevel
 	compression    sch.CompressionCodec
+	encoding       sch.Encoding
 	RepetitionType FieldFunc
 	Types          []int
 	repeated       bool
@@ -186,8 +204,9 @@ func (f *OptionalField) DoWrite(w io.Writer, meta *Metadata, vals []byte, count
 	repLen := wc.n - defLen
 
 	wc.Write(vals)
-	l, cl, vals := compress(f.compression, buf.Bytes())
-	if err := meta.WritePageHeader(w, f.pth, l, cl, len(f.Defs), count, defLen, repLen, f.compression, stats); err != nil {
+	l, cl, vals, done := compress(f.compression, buf.Bytes())
+	defer done()
+	if err := meta.writePageHeader(w, f.pth, f.encoding, l, cl, len(f.Defs), count, defLen, repLen, f.compression, stats); err != nil {
 		return err
 	}
 	_, err = w.Write(vals)
@@ -208,6 +227,9 @@ func (f *OptionalField) DoRead(r io.ReadSeeker, pg Page) (io.Reader, []int, erro
 		if err != nil {
 			return nil, nil, err
 		}
+		if ph.Type != sch.PageType_DATA_PAGE {
+			return nil, nil, fmt.Errorf("column %s: unsupported page type %s", f.Name(), ph.Type)
+		}
 
 		data, err := pageData(rc, ph, pg)
 		if err != nil {
@@ -231,7 +253,11 @@ func (f *OptionalField) DoRead(r io.ReadSeeker, pg Page) (io.Reader, []int, erro
 
 		n := f.valsFromDefs(defs, uint8(f.MaxLevels.Def))
 		sizes = append(sizes, n)
-		out = append(out, data[l:]...)
+		vals, err := plainValues(ph.DataPageHeader.Encoding, data[l:], n)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, vals...)
 		nRead += int(rc.n)
 	}
 	return bytes.NewBuffer(out), sizes, nil
@@ -277,33 +303,39 @@ func (r *readCounter) Read(p []byte) (int, error) {
 }
 
 func pageData(r io.Reader, ph *sch.PageHeader, pg Page) ([]byte, error) {
-	var data []byte
-	switch pg.Codec {
-	case sch.CompressionCodec_SNAPPY:
-		compressed := make([]byte, ph.CompressedPageSize)
-		if _, err := r.Read(compressed); err != nil {
-			return nil, err
-		}
+	compressed := make([]byte, ph.CompressedPageSize)
+	if _, err := io.ReadFull(r, compressed); err != nil {
+		return nil, err
+	}
+	return decompress(pg.Codec, compressed, int(ph.UncompressedPageSize))
+}
 
-		var err error
-		data, err = snappy.Decode(nil, compressed)
+// decompress reverses compress, size is the uncompressed length.
+func decompress(codec sch.CompressionCodec, data []byte, size int) ([]byte, error) {
+	switch codec {
+	case sch.CompressionCodec_SNAPPY:
+		return snappy.Decode(nil, data)
+	case sch.CompressionCodec_GZIP:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
 		if err != nil {
 			return nil, err
 		}
-	case sch.CompressionCodec_UNCOMPRESSED:
-		data = make([]byte, ph.UncompressedPageSize)
-		if _, err := r.Read(data); err != nil {
+		out := make([]byte, size)
+		if _, err := io.ReadFull(gz, out); err != nil {
 			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("unsupported column chunk codec: %s", pg.Codec)
+		return out, gz.Close()
+	case sch.CompressionCodec_UNCOMPRESSED:
+		return data, nil
 	}
-
-	return data, nil
+	return nil, fmt.Errorf("unsupported column chunk codec: %s", codec)
 }
 
-func compress(codec sch.CompressionCodec, vals []byte) (int, int, []byte) {
-	var l, cl int
+// compress returns the uncompressed and compressed length and the compressed
+// data.  The data may live in a pooled buffer, so it is only valid until
+// done is called.
+func compress(codec sch.CompressionCodec, vals []byte) (l int, cl int, out []byte, done func()) {
+	done = func() {}
 	switch codec {
 	case sch.CompressionCodec_SNAPPY:
 		l = len(vals)
@@ -312,7 +344,7 @@ func compress(codec sch.CompressionCodec, vals []byte) (int, int, []byte) {
 		buf.GrowN(el)
 		vals = snappy.Encode(buf.Bytes(), vals)
 		cl = len(vals)
-		putBuffer(buf)
+		done = func() { putBuffer(buf) }
 	case sch.CompressionCodec_GZIP:
 		l = len(vals)
 		gz := GetGzipWriter()
@@ -322,33 +354,42 @@ func compress(codec sch.CompressionCodec, vals []byte) (int, int, []byte) {
 		gz.Close()
 		vals = buf.Bytes()
 		cl = len(vals)
-		PutBuffer(buf)
 		PutGzipWriter(gz)
+		done = func() { PutBuffer(buf) }
 	case sch.CompressionCodec_UNCOMPRESSED:
 		l = len(vals)
 		cl = len(vals)
 	}
-	return l, cl, vals
+	return l, cl, vals, done
 }
 
 // writeLevels writes vals to w as RLE/bitpack encoded data
 func writeLevels(w io.Writer, levels []uint8, width int32) error {
-	enc, _ := rle.New(width, len(levels)) //TODO: len(levels) is probably too big.  Chop it down a bit?
+	enc, err := rle.New(width, len(levels))
+	if err != nil {
+		return err
+	}
 	for _, l := range levels {
-		enc.Write(l)
+		enc.Write(uint32(l))
 	}
-	_, err := w.Write(enc.Bytes())
+	_, err = w.Write(enc.Bytes())
 	return err
 }
 
 // readLevels reads the RLE/bitpack encoded definition and repetition levels
 func readLevels(in io.Reader, width int32) ([]uint8, int, error) {
-	var out []uint8
-	dec, _ := rle.New(width, 0)
-	out, n, err := dec.Read(in)
+	dec, err := rle.New(width, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	vals, n, err := dec.Read(in)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	out := make([]uint8, len(vals))
+	for i, v := range vals {
+		out[i] = uint8(v)
+	}
 	return out, n, nil
 }
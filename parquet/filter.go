@@ -0,0 +1,721 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// Predicate selects records by the values of their columns.  A
+// ParquetReader with a predicate skips the row groups that cannot hold a
+// matching record, using the statistics of their column chunks, the
+// column indexes and the bloom filters.  Of the remaining row groups, it
+// only reads the pages that may hold a matching record when the chunks
+// have column and offset indexes, and then drops the records that do not
+// match.
+type Predicate interface {
+	bind(s *Schema) (filter, error)
+}
+
+// filter is a Predicate whose literals have been converted to the types
+// of the columns of a schema.
+type filter interface {
+	columns(add func(name string))
+	mayMatch(g *rowGroupStats) bool
+	// rowRanges returns the rows of the row group that may match, as
+	// told by the page indexes of its chunks.
+	rowRanges(g *rowGroupStats) []rowRange
+	match(record map[string]interface{}) bool
+	// decide reports whether the records whose columns in known have
+	// these values match, ok is false if that depends on other columns.
+	decide(known map[string]interface{}) (match, ok bool)
+}
+
+const (
+	opEq = iota
+	opLt
+	opGt
+)
+
+type comparison struct {
+	op     int
+	column string
+	values []interface{}
+}
+
+// Eq matches the records whose column equals v.
+func Eq(column string, v interface{}) Predicate {
+	return comparison{op: opEq, column: column, values: []interface{}{v}}
+}
+
+// Lt matches the records whose column is less than v.
+func Lt(column string, v interface{}) Predicate {
+	return comparison{op: opLt, column: column, values: []interface{}{v}}
+}
+
+// Gt matches the records whose column is greater than v.
+func Gt(column string, v interface{}) Predicate {
+	return comparison{op: opGt, column: column, values: []interface{}{v}}
+}
+
+// In matches the records whose column equals one of vs.
+func In(column string, vs ...interface{}) Predicate {
+	return comparison{op: opEq, column: column, values: vs}
+}
+
+func (c comparison) bind(s *Schema) (filter, error) {
+	f := s.Field(c.column)
+	if f == nil {
+		return nil, fmt.Errorf("filter column %s is not in the file", c.column)
+	}
+	b := &comparison{op: c.op, column: c.column, values: make([]interface{}, len(c.values))}
+	for i, v := range c.values {
+		x, err := coerceField(f, v)
+		if err != nil {
+			return nil, fmt.Errorf("filter column %s: %s", c.column, err)
+		}
+		b.values[i] = x
+	}
+	return b, nil
+}
+
+func (c *comparison) columns(add func(string)) { add(c.column) }
+
+func (c *comparison) mayMatch(g *rowGroupStats) bool {
+	s := g.column(c.column)
+	if s == nil {
+		return true
+	}
+	if n, ok := s.nulls(); ok && n == s.ch.MetaData.NumValues {
+		return false
+	}
+	for _, v := range c.values {
+		if c.mayContain(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparison) mayContain(s *columnStats, v interface{}) bool {
+	if !c.inRange(s.min(), s.max(), v) {
+		return false
+	}
+	if idx := s.columnIndex(); idx != nil && len(idx.MinValues) == len(idx.NullPages) && len(idx.MaxValues) == len(idx.NullPages) {
+		found := false
+		for i, null := range idx.NullPages {
+			if !null && c.inRange(StatValue(s.se, idx.MinValues[i]), StatValue(s.se, idx.MaxValues[i]), v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if c.op == opEq {
+		if b := s.bloomFilter(); b != nil {
+			if h, err := BloomFilterHash(v); err == nil && !b.Check(h) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *comparison) rowRanges(g *rowGroupStats) []rowRange {
+	s := g.column(c.column)
+	if s == nil {
+		return g.allRows()
+	}
+	idx, oi := s.columnIndex(), s.offsetIndex()
+	if idx == nil || oi == nil || len(oi.PageLocations) != len(idx.NullPages) ||
+		len(idx.MinValues) != len(idx.NullPages) || len(idx.MaxValues) != len(idx.NullPages) {
+		return g.allRows()
+	}
+	var ranges []rowRange
+	for i, null := range idx.NullPages {
+		if null {
+			continue
+		}
+		for _, v := range c.values {
+			if c.inRange(StatValue(s.se, idx.MinValues[i]), StatValue(s.se, idx.MaxValues[i]), v) {
+				ranges = addRange(ranges, pageRows(oi, i, g.rg.NumRows))
+				break
+			}
+		}
+	}
+	return ranges
+}
+
+// inRange reports whether a value between min and max may satisfy the
+// comparison with v, unknown bounds are nil.
+func (c *comparison) inRange(min, max, v interface{}) bool {
+	switch c.op {
+	case opEq:
+		return (min == nil || compareValues(v, min) >= 0) && (max == nil || compareValues(v, max) <= 0)
+	case opLt:
+		return min == nil || compareValues(min, v) < 0
+	case opGt:
+		return max == nil || compareValues(max, v) > 0
+	}
+	return true
+}
+
+func (c *comparison) decide(known map[string]interface{}) (bool, bool) {
+	if _, ok := known[c.column]; !ok {
+		return false, false
+	}
+	return c.match(known), true
+}
+
+func (c *comparison) match(record map[string]interface{}) bool {
+	rv := record[c.column]
+	if rv == nil {
+		return false
+	}
+	for _, v := range c.values {
+		n := compareValues(rv, v)
+		switch {
+		case c.op == opEq && n == 0, c.op == opLt && n < 0, c.op == opGt && n > 0:
+			return true
+		}
+	}
+	return false
+}
+
+type isNull struct {
+	column string
+}
+
+// IsNull matches the records whose column is null.
+func IsNull(column string) Predicate {
+	return isNull{column: column}
+}
+
+func (p isNull) bind(s *Schema) (filter, error) {
+	if s.Field(p.column) == nil {
+		return nil, fmt.Errorf("filter column %s is not in the file", p.column)
+	}
+	return &p, nil
+}
+
+func (p *isNull) columns(add func(string)) { add(p.column) }
+
+func (p *isNull) mayMatch(g *rowGroupStats) bool {
+	s := g.column(p.column)
+	if s == nil {
+		return true
+	}
+	if n, ok := s.nulls(); ok {
+		return n > 0
+	}
+	if idx := s.columnIndex(); idx != nil && idx.NullCounts != nil {
+		for _, n := range idx.NullCounts {
+			if n > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (p *isNull) rowRanges(g *rowGroupStats) []rowRange {
+	s := g.column(p.column)
+	if s == nil {
+		return g.allRows()
+	}
+	idx, oi := s.columnIndex(), s.offsetIndex()
+	if idx == nil || oi == nil || idx.NullCounts == nil || len(idx.NullCounts) != len(oi.PageLocations) {
+		return g.allRows()
+	}
+	var ranges []rowRange
+	for i, n := range idx.NullCounts {
+		if n > 0 {
+			ranges = addRange(ranges, pageRows(oi, i, g.rg.NumRows))
+		}
+	}
+	return ranges
+}
+
+func (p *isNull) match(record map[string]interface{}) bool {
+	return record[p.column] == nil
+}
+
+func (p *isNull) decide(known map[string]interface{}) (bool, bool) {
+	if _, ok := known[p.column]; !ok {
+		return false, false
+	}
+	return p.match(known), true
+}
+
+type and []Predicate
+
+// And matches the records that match all of ps.
+func And(ps ...Predicate) Predicate {
+	return and(ps)
+}
+
+func (a and) bind(s *Schema) (filter, error) {
+	fs, err := bindAll(s, a)
+	return andFilter(fs), err
+}
+
+type andFilter []filter
+
+func (a andFilter) columns(add func(string)) {
+	for _, f := range a {
+		f.columns(add)
+	}
+}
+
+func (a andFilter) mayMatch(g *rowGroupStats) bool {
+	for _, f := range a {
+		if !f.mayMatch(g) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a andFilter) rowRanges(g *rowGroupStats) []rowRange {
+	ranges := g.allRows()
+	for _, f := range a {
+		ranges = intersectRanges(ranges, f.rowRanges(g))
+	}
+	return ranges
+}
+
+func (a andFilter) match(record map[string]interface{}) bool {
+	for _, f := range a {
+		if !f.match(record) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a andFilter) decide(known map[string]interface{}) (bool, bool) {
+	decided := true
+	for _, f := range a {
+		match, ok := f.decide(known)
+		if ok && !match {
+			return false, true
+		}
+		decided = decided && ok
+	}
+	return decided, decided
+}
+
+type or []Predicate
+
+// Or matches the records that match any of ps.
+func Or(ps ...Predicate) Predicate {
+	return or(ps)
+}
+
+func (o or) bind(s *Schema) (filter, error) {
+	fs, err := bindAll(s, o)
+	return orFilter(fs), err
+}
+
+type orFilter []filter
+
+func (o orFilter) columns(add func(string)) {
+	for _, f := range o {
+		f.columns(add)
+	}
+}
+
+func (o orFilter) mayMatch(g *rowGroupStats) bool {
+	for _, f := range o {
+		if f.mayMatch(g) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o orFilter) rowRanges(g *rowGroupStats) []rowRange {
+	var ranges []rowRange
+	for _, f := range o {
+		ranges = unionRanges(ranges, f.rowRanges(g))
+	}
+	return ranges
+}
+
+func (o orFilter) match(record map[string]interface{}) bool {
+	for _, f := range o {
+		if f.match(record) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o orFilter) decide(known map[string]interface{}) (bool, bool) {
+	decided := true
+	for _, f := range o {
+		match, ok := f.decide(known)
+		if ok && match {
+			return true, true
+		}
+		decided = decided && ok
+	}
+	return false, decided
+}
+
+type not struct {
+	p Predicate
+}
+
+// Not matches the records that do not match p.  Statistics only tell
+// which values a row group may hold, so Not never skips a row group.
+func Not(p Predicate) Predicate {
+	return not{p: p}
+}
+
+func (n not) bind(s *Schema) (filter, error) {
+	f, err := n.p.bind(s)
+	if err != nil {
+		return nil, err
+	}
+	return notFilter{f: f}, nil
+}
+
+type notFilter struct {
+	f filter
+}
+
+func (n notFilter) columns(add func(string))                 { n.f.columns(add) }
+func (n notFilter) mayMatch(g *rowGroupStats) bool           { return true }
+func (n notFilter) rowRanges(g *rowGroupStats) []rowRange    { return g.allRows() }
+func (n notFilter) match(record map[string]interface{}) bool { return !n.f.match(record) }
+
+func (n notFilter) decide(known map[string]interface{}) (bool, bool) {
+	match, ok := n.f.decide(known)
+	return !match, ok
+}
+
+func bindAll(s *Schema, ps []Predicate) ([]filter, error) {
+	fs := make([]filter, len(ps))
+	for i, p := range ps {
+		f, err := p.bind(s)
+		if err != nil {
+			return nil, err
+		}
+		fs[i] = f
+	}
+	return fs, nil
+}
+
+// coerce converts a literal of a filter to the Go type of the values of
+// columns of type t.
+func coerce(t sh.Type, v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	switch t {
+	case sh.Type_INT32, sh.Type_INT64:
+		var i int64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt64 {
+				return nil, fmt.Errorf("%v overflows %s", v, t)
+			}
+			i = int64(rv.Uint())
+		default:
+			return nil, fmt.Errorf("cannot compare %T with %s", v, t)
+		}
+		if t == sh.Type_INT64 {
+			return i, nil
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("%v overflows %s", v, t)
+		}
+		return int32(i), nil
+	case sh.Type_FLOAT, sh.Type_DOUBLE:
+		var f float64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = rv.Float()
+		default:
+			return nil, fmt.Errorf("cannot compare %T with %s", v, t)
+		}
+		if t == sh.Type_FLOAT {
+			return float32(f), nil
+		}
+		return f, nil
+	case sh.Type_BYTE_ARRAY:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case []byte:
+			return string(x), nil
+		}
+	case sh.Type_BOOLEAN:
+		if x, ok := v.(bool); ok {
+			return x, nil
+		}
+	case sh.Type_FIXED_LEN_BYTE_ARRAY:
+		switch x := v.(type) {
+		case string:
+			return []byte(x), nil
+		case []byte:
+			return x, nil
+		}
+	case sh.Type_INT96:
+		if x, ok := v.(time.Time); ok {
+			return x.UTC(), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot compare %T with %s", v, t)
+}
+
+// coerceField converts v to the type of the values of the field f, as
+// the reader returns them.
+func coerceField(f *SchemaField, v interface{}) (interface{}, error) {
+	if f.intType != nil {
+		if x := convertInt(v, f.intType, nil); x != nil {
+			return x, nil
+		}
+		return nil, fmt.Errorf("cannot compare %v with %d bits wide integers", v, f.intType.BitWidth)
+	}
+	x, err := coerce(f.fieldType, v)
+	if s, ok := x.(string); ok && f.binary {
+		return []byte(s), err
+	}
+	return x, err
+}
+
+// rowGroupStats gives the filters access to the statistics of the
+// column chunks of a row group, the column indexes and bloom filters
+// are only read when a filter asks for them.
+type rowGroupStats struct {
+	r      io.ReadSeeker
+	footer *sh.FileMetaData
+	rg     *sh.RowGroup
+	cols   map[string]*columnStats
+	// names maps the columns of a reader schema to the columns of the
+	// file, the columns that are not in it have no statistics.
+	names map[string]string
+}
+
+func newRowGroupStats(r io.ReadSeeker, footer *sh.FileMetaData, rg *sh.RowGroup) *rowGroupStats {
+	return &rowGroupStats{r: r, footer: footer, rg: rg, cols: map[string]*columnStats{}}
+}
+
+// column returns the statistics of the column, nil if they are unknown.
+func (g *rowGroupStats) column(name string) *columnStats {
+	if s, ok := g.cols[name]; ok {
+		return s
+	}
+	var s *columnStats
+	path := name
+	if g.names != nil {
+		path = g.names[name]
+	}
+	ch := findColumnChunk(g.rg, path)
+	se := leafElement(g.footer, path)
+	if ch != nil && ch.MetaData != nil && se != nil {
+		s = &columnStats{r: g.r, se: se, ch: ch}
+	}
+	g.cols[name] = s
+	return s
+}
+
+// allRows returns the range of all rows of the row group.
+func (g *rowGroupStats) allRows() []rowRange {
+	return []rowRange{{0, g.rg.NumRows}}
+}
+
+type columnStats struct {
+	r         io.ReadSeeker
+	se        *sh.SchemaElement
+	ch        *sh.ColumnChunk
+	index     *sh.ColumnIndex
+	indexRead bool
+	offsets   *sh.OffsetIndex
+	offRead   bool
+	bloom     *BloomFilter
+	bloomRead bool
+}
+
+func (s *columnStats) min() interface{} {
+	if sts := s.ch.MetaData.Statistics; sts != nil {
+		return StatValue(s.se, sts.MinValue)
+	}
+	return nil
+}
+
+func (s *columnStats) max() interface{} {
+	if sts := s.ch.MetaData.Statistics; sts != nil {
+		return StatValue(s.se, sts.MaxValue)
+	}
+	return nil
+}
+
+func (s *columnStats) nulls() (int64, bool) {
+	if s.se.RepetitionType != nil && *s.se.RepetitionType == sh.FieldRepetitionType_REQUIRED {
+		return 0, true
+	}
+	if sts := s.ch.MetaData.Statistics; sts != nil && sts.NullCount != nil {
+		return *sts.NullCount, true
+	}
+	return 0, false
+}
+
+// columnIndex reads the column index of the chunk, a missing or
+// unreadable index is nil and never skips anything.
+func (s *columnStats) columnIndex() *sh.ColumnIndex {
+	if s.indexRead {
+		return s.index
+	}
+	s.indexRead = true
+	if s.ch.ColumnIndexOffset == nil {
+		return nil
+	}
+	if _, err := s.r.Seek(*s.ch.ColumnIndexOffset, io.SeekStart); err != nil {
+		return nil
+	}
+	idx := sh.NewColumnIndex()
+	if err := idx.Read(thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: s.r})); err != nil {
+		return nil
+	}
+	s.index = idx
+	return idx
+}
+
+// offsetIndex reads the offset index of the chunk, a missing or
+// unreadable index is nil.
+func (s *columnStats) offsetIndex() *sh.OffsetIndex {
+	if !s.offRead {
+		s.offRead = true
+		s.offsets = readOffsetIndex(s.r, s.ch)
+	}
+	return s.offsets
+}
+
+// readOffsetIndex reads the offset index of the chunk ch, nil if it has
+// none or it cannot be read.
+func readOffsetIndex(r io.ReadSeeker, ch *sh.ColumnChunk) *sh.OffsetIndex {
+	if ch.OffsetIndexOffset == nil {
+		return nil
+	}
+	if _, err := r.Seek(*ch.OffsetIndexOffset, io.SeekStart); err != nil {
+		return nil
+	}
+	oi := sh.NewOffsetIndex()
+	if err := oi.Read(thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: r})); err != nil {
+		return nil
+	}
+	return oi
+}
+
+// bloomFilter reads the bloom filter of the chunk, a missing or
+// unreadable filter is nil.
+func (s *columnStats) bloomFilter() *BloomFilter {
+	if s.bloomRead {
+		return s.bloom
+	}
+	s.bloomRead = true
+	if s.ch.MetaData.BloomFilterOffset == nil {
+		return nil
+	}
+	b, err := ReadBloomFilter(s.r, *s.ch.MetaData.BloomFilterOffset)
+	if err != nil {
+		return nil
+	}
+	s.bloom = b
+	return b
+}
+
+// rowRange is the rows from up to to of a row group, ranges are sorted
+// and do not overlap.
+type rowRange struct {
+	from, to int64
+}
+
+// pageRows returns the rows of page i of the offset index of a chunk of a
+// row group of the given number of rows.
+func pageRows(oi *sh.OffsetIndex, i int, rows int64) rowRange {
+	r := rowRange{from: oi.PageLocations[i].FirstRowIndex, to: rows}
+	if i+1 < len(oi.PageLocations) {
+		r.to = oi.PageLocations[i+1].FirstRowIndex
+	}
+	return r
+}
+
+// addRange adds r to ranges, r starts at or after the last of them.
+func addRange(ranges []rowRange, r rowRange) []rowRange {
+	if n := len(ranges); n > 0 && ranges[n-1].to >= r.from {
+		if r.to > ranges[n-1].to {
+			ranges[n-1].to = r.to
+		}
+		return ranges
+	}
+	return append(ranges, r)
+}
+
+func unionRanges(a, b []rowRange) []rowRange {
+	var ranges []rowRange
+	for len(a) > 0 || len(b) > 0 {
+		if len(b) == 0 || len(a) > 0 && a[0].from <= b[0].from {
+			ranges, a = addRange(ranges, a[0]), a[1:]
+		} else {
+			ranges, b = addRange(ranges, b[0]), b[1:]
+		}
+	}
+	return ranges
+}
+
+func intersectRanges(a, b []rowRange) []rowRange {
+	var ranges []rowRange
+	for len(a) > 0 && len(b) > 0 {
+		r := a[0]
+		if b[0].from > r.from {
+			r.from = b[0].from
+		}
+		if b[0].to < r.to {
+			r.to = b[0].to
+		}
+		if r.from < r.to {
+			ranges = append(ranges, r)
+		}
+		if a[0].to < b[0].to {
+			a = a[1:]
+		} else {
+			b = b[1:]
+		}
+	}
+	return ranges
+}
+
+// overlaps reports whether r has rows in ranges.
+func overlaps(ranges []rowRange, r rowRange) bool {
+	for _, x := range ranges {
+		if x.from < r.to && r.from < x.to {
+			return true
+		}
+	}
+	return false
+}
+
+// countRows returns the number of rows in ranges.
+func countRows(ranges []rowRange) int64 {
+	var n int64
+	for _, r := range ranges {
+		n += r.to - r.from
+	}
+	return n
+}
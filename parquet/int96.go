@@ -0,0 +1,33 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// julianUnixEpoch is the julian day of 1970-01-01.
+const julianUnixEpoch = 2440588
+
+// TimeToInt96 returns the INT96 encoding of t used by Impala and Hive:
+// the nanoseconds of the day followed by the julian day, little endian.
+func TimeToInt96(t time.Time) []byte {
+	t = t.UTC()
+	secs := t.Unix()
+	days := secs / 86400
+	if secs%86400 < 0 {
+		days--
+	}
+	nanos := (secs-days*86400)*int64(time.Second) + int64(t.Nanosecond())
+	b := make([]byte, 12)
+	binary.LittleEndian.PutUint64(b, uint64(nanos))
+	binary.LittleEndian.PutUint32(b[8:], uint32(days+julianUnixEpoch))
+	return b
+}
+
+// Int96ToTime decodes an INT96 timestamp written by TimeToInt96, the
+// time is in UTC.
+func Int96ToTime(b []byte) time.Time {
+	nanos := int64(binary.LittleEndian.Uint64(b))
+	days := int64(binary.LittleEndian.Uint32(b[8:])) - julianUnixEpoch
+	return time.Unix(days*86400, nanos).UTC()
+}
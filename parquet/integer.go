@@ -0,0 +1,183 @@
+package parquet
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// avroIntTypes are the logical types of avro int and long fields that
+// make them small or unsigned integer columns.
+var avroIntTypes = map[string]sh.IntType{
+	"int8":   {BitWidth: 8, IsSigned: true},
+	"int16":  {BitWidth: 16, IsSigned: true},
+	"uint8":  {BitWidth: 8},
+	"uint16": {BitWidth: 16},
+	"uint32": {BitWidth: 32},
+	"uint64": {BitWidth: 64},
+}
+
+// avroIntType returns the IntType of an avro field of type t whose
+// logical type is name, nil if name is not an integer logical type.
+func avroIntType(t sh.Type, name string) (*sh.IntType, error) {
+	it, ok := avroIntTypes[name]
+	if !ok {
+		return nil, nil
+	}
+	if intPhysicalType(&it) != t {
+		return nil, fmt.Errorf("logical type %s needs a %s column, not %s", name, intPhysicalType(&it), t)
+	}
+	return &it, nil
+}
+
+// intPhysicalType returns the type of the columns of integers of type it.
+func intPhysicalType(it *sh.IntType) sh.Type {
+	if it.BitWidth == 64 {
+		return sh.Type_INT64
+	}
+	return sh.Type_INT32
+}
+
+// IntegerType returns the type of a column of integers of bitWidth 8,
+// 16, 32 or 64 bits, annotated with the INTEGER logical type and the
+// matching converted type.
+func IntegerType(bitWidth int8, signed bool) FieldFunc {
+	return func(se *sh.SchemaElement) {
+		it := &sh.IntType{BitWidth: bitWidth, IsSigned: signed}
+		t := intPhysicalType(it)
+		se.Type = &t
+		ct := intConvertedTypes[*it]
+		se.ConvertedType = &ct
+		se.LogicalType = &sh.LogicalType{INTEGER: it}
+	}
+}
+
+var intConvertedTypes = map[sh.IntType]sh.ConvertedType{
+	{BitWidth: 8, IsSigned: true}:   sh.ConvertedType_INT_8,
+	{BitWidth: 16, IsSigned: true}:  sh.ConvertedType_INT_16,
+	{BitWidth: 32, IsSigned: true}:  sh.ConvertedType_INT_32,
+	{BitWidth: 64, IsSigned: true}:  sh.ConvertedType_INT_64,
+	{BitWidth: 8, IsSigned: false}:  sh.ConvertedType_UINT_8,
+	{BitWidth: 16, IsSigned: false}: sh.ConvertedType_UINT_16,
+	{BitWidth: 32, IsSigned: false}: sh.ConvertedType_UINT_32,
+	{BitWidth: 64, IsSigned: false}: sh.ConvertedType_UINT_64,
+}
+
+// intTypeOf returns the IntType of the column se from its logical or
+// converted type, nil if it has neither.
+func intTypeOf(se *sh.SchemaElement) *sh.IntType {
+	if se.IsSetLogicalType() && se.LogicalType.IsSetINTEGER() {
+		return se.LogicalType.INTEGER
+	}
+	if se.ConvertedType == nil {
+		return nil
+	}
+	for it, ct := range intConvertedTypes {
+		if ct == *se.ConvertedType {
+			it := it
+			return &it
+		}
+	}
+	return nil
+}
+
+// intValue converts the bits of an integer column to the Go type of the
+// values of type it: int8, int16, int32, int64 or their unsigned types.
+func intValue(it *sh.IntType, v int64) interface{} {
+	switch {
+	case it.IsSigned && it.BitWidth == 8:
+		return int8(v)
+	case it.IsSigned && it.BitWidth == 16:
+		return int16(v)
+	case it.IsSigned && it.BitWidth == 32:
+		return int32(v)
+	case it.IsSigned:
+		return v
+	case it.BitWidth == 8:
+		return uint8(v)
+	case it.BitWidth == 16:
+		return uint16(v)
+	case it.BitWidth == 32:
+		return uint32(v)
+	}
+	return uint64(v)
+}
+
+// intBits returns the bits of an integer value returned by intValue.
+func intBits(v interface{}) int64 {
+	switch x := v.(type) {
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case uint64:
+		return int64(x)
+	}
+	return 0
+}
+
+// convertInt converts val to an integer of type it, or returns defV if
+// it is not a number in its range.  uint64 values above the int64 range
+// need to be passed as integers or strings, as float64 they lose bits.
+func convertInt(val interface{}, it *sh.IntType, defV interface{}) interface{} {
+	var u uint64
+	var neg bool
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int()
+		neg, u = n < 0, uint64(n)
+		if neg {
+			u = -u
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u = rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.Abs(f) >= math.Exp2(64) {
+			return defV
+		}
+		neg, u = f < 0, uint64(math.Abs(f))
+	case reflect.String:
+		if n, e := strconv.ParseInt(rv.String(), 10, 64); e == nil {
+			neg, u = n < 0, uint64(n)
+			if neg {
+				u = -u
+			}
+		} else if n, e := strconv.ParseUint(rv.String(), 10, 64); e == nil {
+			u = n
+		} else {
+			return defV
+		}
+	default:
+		return defV
+	}
+
+	if it.IsSigned {
+		limit := uint64(1) << uint(it.BitWidth-1)
+		if neg && u > limit || !neg && u >= limit {
+			return defV
+		}
+		if neg {
+			return intValue(it, -int64(u))
+		}
+		return intValue(it, int64(u))
+	}
+	if neg && u != 0 || it.BitWidth < 64 && u >= uint64(1)<<uint(it.BitWidth) {
+		return defV
+	}
+	return intValue(it, int64(u))
+}
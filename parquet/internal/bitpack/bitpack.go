@@ -1,139 +1,116 @@
+// Package bitpack packs groups of 8 values of 1 to 32 bits, least
+// significant bits first, as in the bit-packed runs of the parquet
+// RLE/bit-packing hybrid encoding.
 package bitpack
 
-// Code generated by github.com/parsyl/parquet.  DO NOT EDIT.
+// MaxWidth is the widest supported bit width.
+const MaxWidth = 32
 
-func Pack(width int, vals []uint8) []byte {
-	switch width {
-	case 1:
-		return pack1(vals)
-	case 2:
-		return pack2(vals)
-	case 3:
-		return pack3(vals)
-	case 4:
-		return pack4(vals)
-	default:
-		return []byte{}
-	}
-}
-
-func pack1(vals []uint8) []byte {
-	return []byte{
-		(byte((vals[0]&1)<<0) |
-			byte((vals[1]&1)<<1) |
-			byte((vals[2]&1)<<2) |
-			byte((vals[3]&1)<<3) |
-			byte((vals[4]&1)<<4) |
-			byte((vals[5]&1)<<5) |
-			byte((vals[6]&1)<<6) |
-			byte((vals[7]&1)<<7)),
-	}
-}
-
-func pack2(vals []uint8) []byte {
-	return []byte{
-		(byte((vals[0]&3)<<0) |
-			byte((vals[1]&3)<<2) |
-			byte((vals[2]&3)<<4) |
-			byte((vals[3]&3)<<6)),
-		(byte((vals[4]&3)<<0) |
-			byte((vals[5]&3)<<2) |
-			byte((vals[6]&3)<<4) |
-			byte((vals[7]&3)<<6)),
-	}
-}
-
-func pack3(vals []uint8) []byte {
-	return []byte{
-		(byte((vals[0]&7)<<0) |
-			byte((vals[1]&7)<<3) |
-			byte((vals[2]&3)<<6)),
-		(byte((vals[2]&4)>>2) |
-			byte((vals[3]&7)<<1) |
-			byte((vals[4]&7)<<4) |
-			byte((vals[5]&1)<<7)),
-		(byte((vals[5]&6)>>1) |
-			byte((vals[6]&7)<<2) |
-			byte((vals[7]&7)<<5)),
-	}
+// Pack packs the 8 values of vals into width bytes.
+func Pack(width int, vals []uint32) []byte {
+	return AppendPack(make([]byte, 0, width), width, vals)
 }
 
-func pack4(vals []uint8) []byte {
-	return []byte{
-		(byte((vals[0]&15)<<0) |
-			byte((vals[1]&15)<<4)),
-		(byte((vals[2]&15)<<0) |
-			byte((vals[3]&15)<<4)),
-		(byte((vals[4]&15)<<0) |
-			byte((vals[5]&15)<<4)),
-		(byte((vals[6]&15)<<0) |
-			byte((vals[7]&15)<<4)),
+// AppendPack appends the 8 values of vals, packed into width bytes,
+// to dst.  Widths outside 1 to 32 append nothing.
+func AppendPack(dst []byte, width int, vals []uint32) []byte {
+	if width < 1 || width > MaxWidth {
+		return dst
 	}
-}
-
-func Unpack(width int, vals []byte) []uint8 {
+	_ = vals[7]
 	switch width {
 	case 1:
-		return unpack1(vals)
-	case 2:
-		return unpack2(vals)
-	case 3:
-		return unpack3(vals)
-	case 4:
-		return unpack4(vals)
-	default:
-		return []uint8{}
+		return append(dst, byte(vals[0]&1)|
+			byte(vals[1]&1)<<1|
+			byte(vals[2]&1)<<2|
+			byte(vals[3]&1)<<3|
+			byte(vals[4]&1)<<4|
+			byte(vals[5]&1)<<5|
+			byte(vals[6]&1)<<6|
+			byte(vals[7]&1)<<7)
+	case 8:
+		for _, v := range vals[:8] {
+			dst = append(dst, byte(v))
+		}
+		return dst
+	case 16:
+		for _, v := range vals[:8] {
+			dst = append(dst, byte(v), byte(v>>8))
+		}
+		return dst
+	case 32:
+		for _, v := range vals[:8] {
+			dst = append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+		}
+		return dst
 	}
-}
 
-func unpack1(vals []byte) []uint8 {
-	return []uint8{
-		(uint8(vals[0]&1) >> 0),
-		(uint8(vals[0]&2) >> 1),
-		(uint8(vals[0]&4) >> 2),
-		(uint8(vals[0]&8) >> 3),
-		(uint8(vals[0]&16) >> 4),
-		(uint8(vals[0]&32) >> 5),
-		(uint8(vals[0]&64) >> 6),
-		(uint8(vals[0]&128) >> 7),
+	mask := uint64(1)<<uint(width) - 1
+	var acc uint64
+	var n uint
+	for _, v := range vals[:8] {
+		acc |= (uint64(v) & mask) << n
+		n += uint(width)
+		for ; n >= 8; n -= 8 {
+			dst = append(dst, byte(acc))
+			acc >>= 8
+		}
 	}
+	return dst
 }
 
-func unpack2(vals []byte) []uint8 {
-	return []uint8{
-		(uint8(vals[0]&3) >> 0),
-		(uint8(vals[0]&12) >> 2),
-		(uint8(vals[0]&48) >> 4),
-		(uint8(vals[0]&192) >> 6),
-		(uint8(vals[1]&3) >> 0),
-		(uint8(vals[1]&12) >> 2),
-		(uint8(vals[1]&48) >> 4),
-		(uint8(vals[1]&192) >> 6),
-	}
+// Unpack unpacks the 8 values packed into the first width bytes of vals.
+func Unpack(width int, vals []byte) []uint32 {
+	out := make([]uint32, 8)
+	UnpackInto(out, width, vals)
+	return out
 }
 
-func unpack3(vals []byte) []uint8 {
-	return []uint8{
-		(uint8(vals[0]&7) >> 0),
-		(uint8(vals[0]&56) >> 3),
-		(uint8(vals[0]&192) >> 6) | (uint8(vals[1]&1) << 2),
-		(uint8(vals[1]&14) >> 1),
-		(uint8(vals[1]&112) >> 4),
-		(uint8(vals[1]&128) >> 7) | (uint8(vals[2]&3) << 1),
-		(uint8(vals[2]&28) >> 2),
-		(uint8(vals[2]&224) >> 5),
+// UnpackInto unpacks the 8 values packed into the first width bytes of
+// vals into out.  Widths outside 1 to 32 leave out untouched.
+func UnpackInto(out []uint32, width int, vals []byte) {
+	if width < 1 || width > MaxWidth {
+		return
+	}
+	_ = out[7]
+	_ = vals[width-1]
+	switch width {
+	case 1:
+		b := vals[0]
+		for i := range out[:8] {
+			out[i] = uint32(b>>uint(i)) & 1
+		}
+		return
+	case 8:
+		for i := range out[:8] {
+			out[i] = uint32(vals[i])
+		}
+		return
+	case 16:
+		for i := range out[:8] {
+			out[i] = uint32(vals[2*i]) | uint32(vals[2*i+1])<<8
+		}
+		return
+	case 32:
+		for i := range out[:8] {
+			b := vals[4*i : 4*i+4]
+			out[i] = uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+		}
+		return
 	}
-}
 
-func unpack4(vals []byte) []uint8 {
-	return []uint8{
-		(uint8(vals[0]&15) >> 0),
-		(uint8(vals[0]&240) >> 4),
-		(uint8(vals[1]&15) >> 0),
-		(uint8(vals[1]&240) >> 4),
-		(uint8(vals[2]&15) >> 0),
-		(uint8(vals[2]&240) >> 4),
-		(uint8(vals[3]&15) >> 0),
-		(uint8(vals[3]&240) >> 4),
+	mask := uint64(1)<<uint(width) - 1
+	var acc uint64
+	var n uint
+	j := 0
+	for i := range out[:8] {
+		for n < uint(width) {
+			acc |= uint64(vals[j]) << n
+			j++
+			n += 8
+		}
+		out[i] = uint32(acc & mask)
+		acc >>= uint(width)
+		n -= uint(width)
 	}
 }
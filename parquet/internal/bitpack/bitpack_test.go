@@ -0,0 +1,55 @@
+package bitpack
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func Test_packRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for width := 0; width <= MaxWidth; width++ {
+		mask := uint32(uint64(1)<<uint(width) - 1)
+		for k := 0; k < 20; k++ {
+			vals := make([]uint32, 8)
+			for i := range vals {
+				vals[i] = rnd.Uint32() & mask
+			}
+			if k == 0 {
+				for i := range vals {
+					vals[i] = mask
+				}
+			}
+			packed := Pack(width, vals)
+			if len(packed) != width {
+				t.Fatalf("width %d: packed into %d bytes", width, len(packed))
+			}
+			out := Unpack(width, append(packed, 0xff))
+			for i := range vals {
+				if out[i] != vals[i] {
+					t.Fatalf("width %d: value %d is %d, expected %d", width, i, out[i], vals[i])
+				}
+			}
+		}
+	}
+}
+
+func Test_packIgnoresHighBits(t *testing.T) {
+	vals := []uint32{0xffffffff, 1, 2, 3, 4, 5, 6, 0xfffffff7}
+	for width := 1; width < MaxWidth; width++ {
+		out := Unpack(width, Pack(width, vals))
+		for i := range vals {
+			if out[i] != vals[i]&(1<<uint(width)-1) {
+				t.Fatalf("width %d: value %d is %d", width, i, out[i])
+			}
+		}
+	}
+}
+
+// the example of the bit-packed encoding of the parquet specification
+func Test_packSpecification(t *testing.T) {
+	packed := Pack(3, []uint32{0, 1, 2, 3, 4, 5, 6, 7})
+	if !bytes.Equal(packed, []byte{0x88, 0xc6, 0xfa}) {
+		t.Fatalf("packed % x", packed)
+	}
+}
@@ -0,0 +1,343 @@
+// Package delta implements the DELTA_BINARY_PACKED, DELTA_LENGTH_BYTE_ARRAY
+// and DELTA_BYTE_ARRAY encodings of the parquet format.
+package delta
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	blockSize     = 128
+	miniBlocks    = 4
+	miniBlockSize = blockSize / miniBlocks
+)
+
+var errShort = errors.New("delta: unexpected end of data")
+
+// AppendInt32 appends the DELTA_BINARY_PACKED encoding of vals to dst.
+func AppendInt32(dst []byte, vals []int32) []byte {
+	vs := make([]int64, len(vals))
+	for i, v := range vals {
+		vs[i] = int64(v)
+	}
+	return appendValues(dst, vs, 32)
+}
+
+// AppendInt64 appends the DELTA_BINARY_PACKED encoding of vals to dst.
+func AppendInt64(dst []byte, vals []int64) []byte {
+	return appendValues(dst, vals, 64)
+}
+
+// appendValues encodes vals, whose deltas wrap around at width bits.
+func appendValues(dst []byte, vals []int64, width uint) []byte {
+	var first int64
+	if len(vals) > 0 {
+		first = vals[0]
+	}
+	dst = appendUvarint(dst, blockSize)
+	dst = appendUvarint(dst, miniBlocks)
+	dst = appendUvarint(dst, uint64(len(vals)))
+	dst = appendVarint(dst, first)
+
+	var deltas [blockSize]int64
+	var packed [miniBlockSize]uint64
+	for start := 1; start < len(vals); start += blockSize {
+		end := start + blockSize
+		if end > len(vals) {
+			end = len(vals)
+		}
+		n := end - start
+		minDelta := int64(0)
+		for i := 0; i < n; i++ {
+			d := vals[start+i] - vals[start+i-1]
+			if width == 32 {
+				d = int64(int32(d))
+			}
+			deltas[i] = d
+			if i == 0 || d < minDelta {
+				minDelta = d
+			}
+		}
+		dst = appendVarint(dst, minDelta)
+
+		var widths [miniBlocks]uint
+		for m := 0; m < miniBlocks; m++ {
+			var max uint64
+			for i := m * miniBlockSize; i < (m+1)*miniBlockSize && i < n; i++ {
+				if v := uint64(deltas[i] - minDelta); v > max {
+					max = v
+				}
+			}
+			widths[m] = uint(bits.Len64(max))
+			dst = append(dst, byte(widths[m]))
+		}
+		for m := 0; m*miniBlockSize < n; m++ {
+			for i := range packed {
+				packed[i] = 0
+				if j := m*miniBlockSize + i; j < n {
+					packed[i] = uint64(deltas[j] - minDelta)
+				}
+			}
+			dst = appendPacked(dst, packed[:], widths[m])
+		}
+	}
+	return dst
+}
+
+// Int32s decodes n DELTA_BINARY_PACKED values, it returns them and the
+// number of bytes they took.
+func Int32s(data []byte, n int) ([]int32, int, error) {
+	vs, l, err := values(data, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]int32, len(vs))
+	for i, v := range vs {
+		out[i] = int32(v)
+	}
+	return out, l, nil
+}
+
+// Int64s decodes n DELTA_BINARY_PACKED values, it returns them and the
+// number of bytes they took.
+func Int64s(data []byte, n int) ([]int64, int, error) {
+	return values(data, n)
+}
+
+func values(data []byte, n int) ([]int64, int, error) {
+	r := reader{data: data}
+	size := r.uvarint()
+	blocks := r.uvarint()
+	total := r.uvarint()
+	prev := r.varint()
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	if blocks == 0 || size/blocks < 8 || size%(8*blocks) != 0 || size/blocks > 1<<16 {
+		return nil, 0, fmt.Errorf("delta: invalid block size %d with %d mini blocks", size, blocks)
+	}
+	// the values after the first take blocks of size values, with a byte
+	// for their min delta and one for the width of each mini block.
+	if rest := uint64(len(data) - r.pos); total > 1 && (blocks > rest || total-1 > rest/(1+blocks)*size) {
+		return nil, 0, fmt.Errorf("delta: %d values in %d bytes", total, rest)
+	}
+	if total < uint64(n) {
+		return nil, 0, fmt.Errorf("delta: %d values for %d rows", total, n)
+	}
+	miniSize := int(size / blocks)
+	out := make([]int64, 0, n)
+	if total > 0 {
+		out = append(out, prev)
+	}
+	var widths []uint
+	var unpacked []uint64
+	for uint64(len(out)) < total {
+		if widths == nil {
+			widths, unpacked = make([]uint, blocks), make([]uint64, miniSize)
+		}
+		minDelta := r.varint()
+		for m := range widths {
+			widths[m] = uint(r.byte())
+			if widths[m] > 64 {
+				return nil, 0, fmt.Errorf("delta: invalid bit width %d", widths[m])
+			}
+		}
+		if r.err != nil {
+			return nil, 0, r.err
+		}
+		for m := 0; m < len(widths) && uint64(len(out)) < total; m++ {
+			if err := r.unpack(unpacked, widths[m]); err != nil {
+				return nil, 0, err
+			}
+			for _, d := range unpacked {
+				if uint64(len(out)) == total {
+					break
+				}
+				prev += minDelta + int64(d)
+				out = append(out, prev)
+			}
+		}
+	}
+	return out[:n], r.pos, nil
+}
+
+// AppendLengthByteArray appends the DELTA_LENGTH_BYTE_ARRAY encoding of
+// vals to dst.
+func AppendLengthByteArray(dst []byte, vals []string) []byte {
+	lengths := make([]int32, len(vals))
+	for i, v := range vals {
+		lengths[i] = int32(len(v))
+	}
+	dst = AppendInt32(dst, lengths)
+	for _, v := range vals {
+		dst = append(dst, v...)
+	}
+	return dst
+}
+
+// LengthByteArrays decodes n DELTA_LENGTH_BYTE_ARRAY values.
+func LengthByteArrays(data []byte, n int) ([]string, int, error) {
+	lengths, l, err := Int32s(data, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]string, n)
+	for i, size := range lengths {
+		if size < 0 || int(size) > len(data)-l {
+			return nil, 0, errShort
+		}
+		out[i] = string(data[l : l+int(size)])
+		l += int(size)
+	}
+	return out, l, nil
+}
+
+// AppendByteArray appends the DELTA_BYTE_ARRAY encoding of vals to dst,
+// each value is stored as the length of the prefix it shares with the
+// previous value and the rest of it.
+func AppendByteArray(dst []byte, vals []string) []byte {
+	prefixes := make([]int32, len(vals))
+	suffixes := make([]string, len(vals))
+	var prev string
+	for i, v := range vals {
+		p := 0
+		for p < len(v) && p < len(prev) && v[p] == prev[p] {
+			p++
+		}
+		prefixes[i] = int32(p)
+		suffixes[i] = v[p:]
+		prev = v
+	}
+	dst = AppendInt32(dst, prefixes)
+	return AppendLengthByteArray(dst, suffixes)
+}
+
+// ByteArrays decodes n DELTA_BYTE_ARRAY values.
+func ByteArrays(data []byte, n int) ([]string, int, error) {
+	prefixes, l, err := Int32s(data, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	suffixes, l2, err := LengthByteArrays(data[l:], n)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]string, n)
+	var prev string
+	for i, p := range prefixes {
+		if p < 0 || int(p) > len(prev) {
+			return nil, 0, fmt.Errorf("delta: prefix of %d bytes of a %d byte value", p, len(prev))
+		}
+		out[i] = prev[:p] + suffixes[i]
+		prev = out[i]
+	}
+	return out, l + l2, nil
+}
+
+func appendUvarint(dst []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(dst, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// appendVarint appends the zigzag ULEB128 encoding of v.
+func appendVarint(dst []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(dst, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+// appendPacked appends vals bit packed at width bits, least significant
+// bits first.
+func appendPacked(dst []byte, vals []uint64, width uint) []byte {
+	if width == 0 {
+		return dst
+	}
+	start := len(dst)
+	dst = append(dst, make([]byte, (len(vals)*int(width)+7)/8)...)
+	out := dst[start:]
+	var bit uint
+	for _, v := range vals {
+		for put := uint(0); put < width; {
+			take := 8 - bit%8
+			if take > width-put {
+				take = width - put
+			}
+			out[bit/8] |= byte((v>>put)&(1<<take-1)) << (bit % 8)
+			put += take
+			bit += take
+		}
+	}
+	return dst
+}
+
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.err = errShort
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *reader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.err = errShort
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.err = errShort
+		return 0
+	}
+	r.pos++
+	return r.data[r.pos-1]
+}
+
+// unpack reads len(out) values bit packed at width bits.
+func (r *reader) unpack(out []uint64, width uint) error {
+	size := (len(out)*int(width) + 7) / 8
+	if len(r.data)-r.pos < size {
+		return errShort
+	}
+	data := r.data[r.pos : r.pos+size]
+	r.pos += size
+	var bit uint
+	for i := range out {
+		var v uint64
+		for got := uint(0); got < width; {
+			b := uint64(data[bit/8]) >> (bit % 8)
+			take := 8 - bit%8
+			if take > width-got {
+				take = width - got
+			}
+			v |= (b & (1<<take - 1)) << got
+			got += take
+			bit += take
+		}
+		out[i] = v
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package delta
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_int64sRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 31, 128, 129, 1000} {
+		vals := make([]int64, n)
+		for i := range vals {
+			vals[i] = int64(i*i) - 500
+			if i%7 == 3 {
+				vals[i] = math.MinInt64 + int64(i)
+			}
+		}
+		data := AppendInt64(nil, vals)
+		out, l, err := Int64s(append(data, 0xff), n)
+		if err != nil {
+			t.Fatalf("%d values: %s", n, err)
+		}
+		if l != len(data) || len(out) != n {
+			t.Fatalf("%d values: decoded %d in %d bytes of %d", n, len(out), l, len(data))
+		}
+		for i := range vals {
+			if out[i] != vals[i] {
+				t.Fatalf("%d values: value %d is %d, expected %d", n, i, out[i], vals[i])
+			}
+		}
+	}
+}
+
+func Test_int64sImplausibleTotal(t *testing.T) {
+	headers := [][]byte{
+		// 2^62 values in a few bytes
+		appendVarint(appendUvarint(appendUvarint(appendUvarint(nil, 128), 4), 1<<62), 0),
+		// more mini blocks than bytes
+		appendVarint(appendUvarint(appendUvarint(appendUvarint(nil, 1<<20), 1<<14), 2), 0),
+		// 8 * mini blocks overflows
+		appendVarint(appendUvarint(appendUvarint(appendUvarint(nil, 128), 1<<61), 2), 0),
+	}
+	for i, data := range headers {
+		data = append(data, 0, 0, 0, 0, 0)
+		if _, _, err := Int64s(data, 1); err == nil {
+			t.Fatalf("header %d: expected an error", i)
+		}
+	}
+}
@@ -3,285 +3,193 @@ package rle
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"github.com/houkx/parquet-go/parquet/internal/bitpack"
 	"io"
-)
+	"math"
 
-const (
-	mask1 = uint64(0x7F)
-	mask2 = uint64(0x80)
+	"github.com/houkx/parquet-go/parquet/internal/bitpack"
 )
 
+// minRepeat is the shortest run of equal values that is written as
+// an RLE run instead of being bit packed.
+const minRepeat = 8
+
+var errShort = errors.New("rle: unexpected end of data")
+
 // RLE holds metadata that is used while reading
 // and writing run length encoded data.
 type RLE struct {
-	// TODO: make out a buffer?
-	out           *writeBuffer
-	bitWidth      int32
-	packBuf       []byte
-	prev          uint8
-	valBuf        []uint8
-	bufCount      int
-	repeatCount   int
-	groupCount    int
-	headerPointer int
+	bitWidth int32
+	vals     []uint32
 }
 
 // New creates an RLE struct based on the maximum bitwidth (width) of
-// the data that is to be encoded/decoded.
+// the data that is to be encoded/decoded.  size is the expected number
+// of values.
 func New(width int32, size int) (*RLE, error) {
-	if width > 4 {
-		return nil, fmt.Errorf("bitwidth %d is greater than 4 (highest supported)", width)
+	if width < 0 || width > bitpack.MaxWidth {
+		return nil, fmt.Errorf("bitwidth %d is greater than %d (highest supported)", width, bitpack.MaxWidth)
 	}
 	return &RLE{
-		out:           newWriteBuffer(size),
-		bitWidth:      width,
-		packBuf:       make([]byte, int(width)),
-		valBuf:        make([]uint8, 8),
-		headerPointer: -1,
+		bitWidth: width,
+		vals:     make([]uint32, 0, size),
 	}, nil
 }
 
 // Write encodes 'value' to run length encoded data.
-func (r *RLE) Write(value uint8) {
-	if value == r.prev {
-		r.repeatCount++
-		if r.repeatCount >= 8 {
-			return
-		}
-	} else {
-		if r.repeatCount >= 8 {
-			r.writeRLERun()
-		}
-		r.repeatCount = 1
-		r.prev = value
-	}
-	r.valBuf[r.bufCount] = value
-	r.bufCount++
-
-	if r.bufCount == 8 {
-		r.writeOrAppendBitPackedRun()
-	}
+func (r *RLE) Write(value uint32) {
+	r.vals = append(r.vals, value)
 }
 
-func (r *RLE) writeOrAppendBitPackedRun() {
-	if r.groupCount >= 63 {
-		r.endPreviousBitPackedRun()
-	}
-
-	if r.headerPointer == -1 {
-		r.out.write([]byte{0})
-		r.headerPointer = r.out.size() - 1
-	}
-
-	r.out.write(bitpack.Pack(int(r.bitWidth), r.valBuf))
-	r.bufCount = 0
-	r.repeatCount = 0
-	r.groupCount++
-}
+// Bytes the raw run length encoded data, prefixed with its length
+// as a 4 byte little endian integer.
+func (r *RLE) Bytes() []byte {
+	out := r.Encode(make([]byte, 4, 4+len(r.vals)*int(r.bitWidth)/8+16))
+	binary.LittleEndian.PutUint32(out, uint32(len(out)-4))
+	return out
+}
+
+// Encode appends the run length encoded data, without a length prefix,
+// to dst.
+func (r *RLE) Encode(dst []byte) []byte {
+	vals := r.vals
+	width := int(r.bitWidth)
+	var group [8]uint32
+	for i := 0; i < len(vals); {
+		if run := repeats(vals, i); run >= minRepeat || width == 0 {
+			dst = appendUvarint(dst, uint64(run)<<1)
+			dst = appendValue(dst, vals[i], width)
+			i += run
+			continue
+		}
 
-func (r *RLE) endPreviousBitPackedRun() {
-	if r.headerPointer == -1 {
-		return
+		// bit pack groups of 8 values until the next long run starts
+		start := i
+		for i < len(vals) {
+			i += 8
+			if i < len(vals) && repeats(vals, i) >= minRepeat {
+				break
+			}
+		}
+		groups := (i - start) / 8
+		dst = appendUvarint(dst, uint64(groups)<<1|1)
+		for g := 0; g < groups; g++ {
+			n := copy(group[:], vals[start+8*g:min(start+8*g+8, len(vals))])
+			for j := n; j < 8; j++ {
+				group[j] = 0
+			}
+			dst = bitpack.AppendPack(dst, width, group[:])
+		}
+		if i > len(vals) {
+			i = len(vals)
+		}
 	}
-
-	bitPackHeader := byte((r.groupCount << 1) | 1)
-	r.out.writeAt([]byte{bitPackHeader}, r.headerPointer)
-	r.headerPointer = -1
-	r.groupCount = 0
+	return dst
 }
 
-func (r *RLE) writeRLERun() error {
-	r.endPreviousBitPackedRun()
-	r.out.write(r.leb128(r.repeatCount << 1))
-	x, err := r.writeIntLittleEndianPaddedOnBitWidth(r.prev, r.bitWidth)
-	if err != nil {
-		return err
+func min(a, b int) int {
+	if a < b {
+		return a
 	}
-	r.out.write(x)
-	r.repeatCount = 0
-	r.bufCount = 0
-	return nil
+	return b
 }
 
-func (r *RLE) writeIntLittleEndianPaddedOnBitWidth(v uint8, bitWidth int32) ([]byte, error) {
-	bytesWidth := (bitWidth + 7) / 8
-	switch bytesWidth {
-	case 0:
-		return nil, nil
-	case 1:
-		return []byte{
-			byte(uint(v>>0) & 0xFF),
-		}, nil
-	case 2:
-		return []byte{
-			byte(uint(v>>0) & 0xFF),
-			byte(uint(v>>8) & 0xFF),
-		}, nil
-	default:
-		return nil, fmt.Errorf("Encountered value (%d) that requires more than 2 bytes", v)
+// repeats returns how often vals[i] repeats from i on.
+func repeats(vals []uint32, i int) int {
+	n := 1
+	for i+n < len(vals) && vals[i+n] == vals[i] {
+		n++
 	}
+	return n
 }
 
-func (r *RLE) leb128(value int) []byte {
-	var out []byte
-	for (value & 0xFFFFFF80) != 0 {
-		out = append(out, byte((value&0x7F)|0x80))
-		value = int(uint(value) >> 7)
-	}
-	return append(out, byte(value&0x7F))
+func appendUvarint(dst []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(dst, buf[:binary.PutUvarint(buf[:], v)]...)
 }
 
-// Bytes the raw run length encoded data.
-func (r *RLE) Bytes() []byte {
-	if r.repeatCount >= 8 {
-		r.writeRLERun()
-	} else if r.bufCount > 0 {
-		for i := r.bufCount; i < 8; i++ {
-			r.valBuf[i] = 0
-		}
-		r.writeOrAppendBitPackedRun()
-		r.endPreviousBitPackedRun()
-	} else {
-		r.endPreviousBitPackedRun()
+// appendValue appends v as a little endian integer of the bytes needed
+// for width bits.
+func appendValue(dst []byte, v uint32, width int) []byte {
+	for i := 0; i < (width+7)/8; i++ {
+		dst = append(dst, byte(v>>(8*uint(i))))
 	}
-
-	var b bytes.Buffer
-	binary.Write(&b, binary.LittleEndian, int32(r.out.size()))
-	return append(b.Bytes(), r.out.bytes()...)
+	return dst
 }
 
-// Read reads the RLE encoded definition levels
-func (r *RLE) Read(in io.Reader) ([]uint8, int, error) {
-	var out []uint8
+// Read reads the RLE encoded definition levels, which are prefixed with
+// their length.  It returns the values, including the padding of the last
+// bit packed run, and the number of bytes read.
+func (r *RLE) Read(in io.Reader) ([]uint32, int, error) {
 	var length int32
 	if err := binary.Read(in, binary.LittleEndian, &length); err != nil {
-		return out, 0, err
+		return nil, 0, err
+	}
+	if length < 0 {
+		return nil, 0, fmt.Errorf("rle: invalid length %d", length)
 	}
 
 	buf := make([]byte, length)
-	if _, err := in.Read(buf); err != nil {
+	if _, err := io.ReadFull(in, buf); err != nil {
+		return nil, 0, err
+	}
+	out, err := r.Decode(buf)
+	if err != nil {
 		return nil, 0, err
 	}
+	return out, int(length) + 4, nil
+}
 
-	rr := bytes.NewReader(buf)
-	var header uint64
-	var vals []uint8
-	var err error
+// Decode decodes run length encoded data without a length prefix.
+func (r *RLE) Decode(data []byte) ([]uint32, error) {
+	var out []uint32
+	width := int(r.bitWidth)
+	rr := bytes.NewReader(data)
 	for rr.Len() > 0 {
-		header, err = readLEB128(rr)
+		header, err := binary.ReadUvarint(rr)
 		if err != nil {
-			return nil, 0, err
+			return nil, errShort
 		}
 		if header&1 == 0 {
-			vals, err = readRLE(rr, header, uint64(r.bitWidth))
+			v, err := readValue(rr, width)
 			if err != nil {
-				return nil, 0, err
+				return nil, err
 			}
-			out = append(out, vals...)
-		} else {
-			vals, err = readRLEBitPacked(rr, header, uint8(r.bitWidth))
-			if err != nil {
-				return nil, 0, err
+			count := header >> 1
+			if count > math.MaxInt32 {
+				return nil, fmt.Errorf("rle: run of %d values", count)
+			}
+			for i := uint64(0); i < count; i++ {
+				out = append(out, v)
 			}
-			out = append(out, vals...)
+			continue
 		}
-	}
-	return out, int(length) + 4, nil
-}
-
-func readRLEBitPacked(r io.Reader, header uint64, width uint8) ([]uint8, error) {
-	count := (int(header) >> 1) * 8
-	if width == 0 {
-		return make([]uint8, count), nil
-	}
-
-	byteCount := (int(width) * count) / 8
-	rawBytes := make([]byte, byteCount)
-	if _, err := r.Read(rawBytes); err != nil {
-		return nil, err
-	}
-
-	var out []uint8
-	for len(rawBytes) > 0 {
-		out = append(out, bitpack.Unpack(int(width), rawBytes[:width])...)
-		rawBytes = rawBytes[int(width):]
-	}
 
-	return out, nil
-}
-
-func readRLE(r io.Reader, header uint64, bitWidth uint64) ([]uint8, error) {
-	count := header >> 1
-	value, err := readIntLittleEndianPaddedOnBitWidth(r, int(bitWidth))
-	if err != nil {
-		return nil, err
-	}
-
-	out := make([]uint8, count)
-	for i := 0; i < int(count); i++ {
-		out[i] = uint8(value)
+		groups := int(header >> 1)
+		if groups*width > rr.Len() {
+			return nil, errShort
+		}
+		if width == 0 {
+			out = append(out, make([]uint32, 8*groups)...)
+			continue
+		}
+		packed := make([]byte, width)
+		for g := 0; g < groups; g++ {
+			rr.Read(packed)
+			n := len(out)
+			out = append(out, 0, 0, 0, 0, 0, 0, 0, 0)
+			bitpack.UnpackInto(out[n:], width, packed)
+		}
 	}
 	return out, nil
 }
 
-func readIntLittleEndianPaddedOnBitWidth(in io.Reader, bitWidth int) (uint8, error) {
-	bytesWidth := (bitWidth + 7) / 8
-	switch bytesWidth {
-	case 0:
-		return 0, nil
-	case 1:
-		return readIntLittleEndianOnOneByte(in)
-	case 2:
-		return readIntLittleEndianOnTwoBytes(in)
-	default:
-		return 0, fmt.Errorf("Encountered bitWidth (%d) that requires more than 4 bytes", bitWidth)
-	}
-}
-
-func readIntLittleEndianOnOneByte(in io.Reader) (uint8, error) {
-	b := make([]byte, 1)
-	_, err := in.Read(b)
-	if err != nil {
-		return 0, err
-	}
-	if b[0] < 0 {
-		return 0, io.EOF
-	}
-	return uint8(b[0]), nil
-}
-
-func readIntLittleEndianOnTwoBytes(in io.Reader) (uint8, error) {
-	b := make([]byte, 2)
-	_, err := in.Read(b)
-	if err != nil {
-		return 0, err
-	}
-	if b[0] < 0 {
-		return 0, io.EOF
-	}
-
-	if (b[0] | b[1]) < 0 {
-		return 0, io.EOF
-	}
-	return (uint8(b[1]) << 8) + (uint8(b[0]) << 0), nil
-}
-
-func readLEB128(r io.Reader) (uint64, error) {
-	var err error
-	var out, shift, x uint64
-	b := make([]byte, 1)
-	for {
-		_, err = r.Read(b)
-		if err != nil {
-			return out, err
-		}
-		x = uint64(b[0])
-		out |= (x & mask1) << shift
-		if (x & mask2) == 0 {
-			return out, nil
-		}
-		shift += 7
+func readValue(in io.Reader, width int) (uint32, error) {
+	var b [4]byte
+	size := (width + 7) / 8
+	if _, err := io.ReadFull(in, b[:size]); err != nil {
+		return 0, errShort
 	}
+	return binary.LittleEndian.Uint32(b[:]), nil
 }
@@ -0,0 +1,107 @@
+package rle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/houkx/parquet-go/parquet/internal/bitpack"
+)
+
+// values returns n values of width bits, with runs of repeated values
+// between bit packed stretches when mixed is set.
+func values(rnd *rand.Rand, width, n int, mixed bool) []uint32 {
+	mask := uint32(uint64(1)<<uint(width) - 1)
+	vals := make([]uint32, 0, n)
+	for len(vals) < n {
+		v := rnd.Uint32() & mask
+		if mixed && rnd.Intn(2) == 0 {
+			for run := minRepeat + rnd.Intn(20); run > 0 && len(vals) < n; run-- {
+				vals = append(vals, v)
+			}
+			continue
+		}
+		vals = append(vals, v)
+	}
+	return vals
+}
+
+// runs returns the number of RLE and of bit packed runs of data.
+func runs(t *testing.T, data []byte, width int) (repeated, packed int) {
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		header, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header&1 == 0 {
+			repeated++
+			r.Seek(int64((width+7)/8), 1)
+			continue
+		}
+		packed++
+		r.Seek(int64(header>>1)*int64(width), 1)
+	}
+	return repeated, packed
+}
+
+func Test_roundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for width := 0; width <= bitpack.MaxWidth; width++ {
+		for _, n := range []int{0, 1, 7, 8, 9, 13, 64, 100, 1001} {
+			for _, mixed := range []bool{false, true} {
+				vals := values(rnd, width, n, mixed)
+				r, err := New(int32(width), n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, v := range vals {
+					r.Write(v)
+				}
+				data := r.Bytes()
+				out, l, err := r.Read(bytes.NewReader(append(data, 0xff)))
+				if err != nil {
+					t.Fatalf("width %d, %d values: %s", width, n, err)
+				}
+				if l != len(data) {
+					t.Fatalf("width %d, %d values: read %d bytes of %d", width, n, l, len(data))
+				}
+				// the last bit packed run is padded to a multiple of 8
+				if len(out) < n || len(out) > n+7 {
+					t.Fatalf("width %d, %d values: decoded %d", width, n, len(out))
+				}
+				for i, v := range vals {
+					if out[i] != v {
+						t.Fatalf("width %d, %d values: value %d is %d, expected %d", width, n, i, out[i], v)
+					}
+				}
+
+				if width > 0 && mixed && n >= 100 {
+					if repeated, packed := runs(t, data[4:], width); repeated == 0 || packed == 0 {
+						t.Fatalf("width %d: %d RLE and %d bit packed runs", width, repeated, packed)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_decodeErrors(t *testing.T) {
+	r, err := New(5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{
+		{3, 1, 2},    // a bit packed group of 5 bytes
+		{4},          // an RLE run without its value
+		{0x80, 0x80}, // an unfinished header
+	} {
+		if _, err := r.Decode(data); err == nil {
+			t.Fatalf("% x: expected an error", data)
+		}
+	}
+	if _, err := New(33, 0); err == nil {
+		t.Fatal("expected an error for 33 bits")
+	}
+}
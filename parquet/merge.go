@@ -0,0 +1,119 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+
+	sch "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// Merge concatenates parquet files with identical schemas into w.  The
+// column chunks are copied byte for byte, without decoding any page, and
+// only their offsets are rewritten in the combined FileMetaData.  Column
+// and offset indexes and bloom filters of the inputs are not copied.
+func Merge(w io.Writer, files ...io.ReadSeeker) error {
+	wc := &writeCounter{w: w}
+	if _, err := wc.Write(PARK_FLAG); err != nil {
+		return err
+	}
+
+	var out *sch.FileMetaData
+	for i, r := range files {
+		footer, err := ReadMetaData(r)
+		if err != nil {
+			return fmt.Errorf("file %d: %s", i, err)
+		}
+		if out == nil {
+			out = &sch.FileMetaData{
+				Version:          footer.Version,
+				Schema:           footer.Schema,
+				KeyValueMetadata: footer.KeyValueMetadata,
+				CreatedBy:        footer.CreatedBy,
+				ColumnOrders:     footer.ColumnOrders,
+			}
+		} else if err := sameSchema(out.Schema, footer.Schema); err != nil {
+			return fmt.Errorf("file %d: %s", i, err)
+		}
+
+		for _, rg := range footer.RowGroups {
+			nrg := *rg
+			nrg.Columns = make([]*sch.ColumnChunk, len(rg.Columns))
+			for j, ch := range rg.Columns {
+				if ch.FilePath != nil {
+					return fmt.Errorf("file %d: column chunks in external files are not supported", i)
+				}
+				nch, err := copyColumnChunk(wc, r, ch)
+				if err != nil {
+					return fmt.Errorf("file %d: %s", i, err)
+				}
+				nrg.Columns[j] = nch
+			}
+			out.RowGroups = append(out.RowGroups, &nrg)
+		}
+		out.NumRows += footer.NumRows
+	}
+	if out == nil {
+		return fmt.Errorf("no files to merge")
+	}
+
+	if err := WriteFooter(wc, out); err != nil {
+		return err
+	}
+	_, err := wc.Write(PARK_FLAG)
+	return err
+}
+
+// copyColumnChunk copies the pages of ch from r to w and returns ch
+// with its offsets moved to where the pages were written.
+func copyColumnChunk(w *writeCounter, r io.ReadSeeker, ch *sch.ColumnChunk) (*sch.ColumnChunk, error) {
+	md := ch.MetaData
+	start := chunkOffset(md)
+	if _, err := PageHeadersAtOffset(r, start, md.NumValues); err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	delta := w.n - start
+	if _, err := io.CopyN(w, r, end-start); err != nil {
+		return nil, err
+	}
+
+	nmd := *md
+	nmd.DataPageOffset += delta
+	if md.DictionaryPageOffset != nil {
+		o := *md.DictionaryPageOffset + delta
+		nmd.DictionaryPageOffset = &o
+	}
+	if md.IndexPageOffset != nil {
+		o := *md.IndexPageOffset + delta
+		nmd.IndexPageOffset = &o
+	}
+	nmd.BloomFilterOffset = nil
+	return &sch.ColumnChunk{
+		FileOffset: ch.FileOffset + delta,
+		MetaData:   &nmd,
+	}, nil
+}
+
+// sameSchema makes sure two footers describe the same columns.
+func sameSchema(a, b []*sch.SchemaElement) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("schema has %d elements, expected %d", len(b), len(a))
+	}
+	for i := 1; i < len(a); i++ {
+		x, y := a[i], b[i]
+		if x.Name != y.Name || x.GetType() != y.GetType() ||
+			x.GetRepetitionType() != y.GetRepetitionType() ||
+			annotation(x) != annotation(y) ||
+			x.GetTypeLength() != y.GetTypeLength() ||
+			x.GetNumChildren() != y.GetNumChildren() {
+			return fmt.Errorf("schema element %s differs from %s", y.Name, x.Name)
+		}
+	}
+	return nil
+}
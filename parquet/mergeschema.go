@@ -0,0 +1,140 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// MergedSchema is the schema of a set of files written over time with
+// slightly different schemas, their records can all be read with it by
+// ParquetReaderSchema.
+type MergedSchema struct {
+	Schema *Schema
+	// Missing holds, in the order of the files, the columns of Schema
+	// each file does not have.
+	Missing [][]string
+}
+
+// MergeSchemas reads the footers of inputs and merges their schemas, see
+// MergeFooters.
+func MergeSchemas(inputs ...io.ReadSeeker) (*MergedSchema, error) {
+	footers := make([]*sh.FileMetaData, len(inputs))
+	for i, r := range inputs {
+		footer, err := ReadMetaData(r)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %s", i, err)
+		}
+		footers[i] = footer
+	}
+	return MergeFooters(footers...)
+}
+
+// MergeFooters merges the schemas of files.  The columns are in the order
+// they first appear, a column is optional unless it is required in every
+// file, and its type is the narrowest type the values of all its columns
+// can be promoted to: integers are widened, integers and floats become
+// doubles when no integer type holds them all, and enums become strings
+// unless they are enums in every file.  BYTE_ARRAY columns without
+// annotation are strings, as by NewSchemaFromFileMetaData.  Types that cannot be merged, such
+// as strings and integers, are an error.  The schema uses the codec of
+// the first column chunk of the first file.
+func MergeFooters(footers ...*sh.FileMetaData) (*MergedSchema, error) {
+	var cols []SchemaColumn
+	index := map[string]int{}
+	seen := make([]map[string]bool, len(footers))
+	for i, footer := range footers {
+		seen[i] = map[string]bool{}
+		for _, se := range footer.Schema[1:] {
+			if se.Type == nil {
+				return nil, fmt.Errorf("file %d: nested column %s is not supported", i, se.Name)
+			}
+			if se.GetRepetitionType() == sh.FieldRepetitionType_REPEATED {
+				return nil, fmt.Errorf("file %d: column %s is repeated, only required and optional columns are supported", i, se.Name)
+			}
+			c := elementColumn(se, false)
+			seen[i][c.Name] = true
+			j, ok := index[c.Name]
+			if !ok {
+				index[c.Name] = len(cols)
+				cols = append(cols, c)
+				continue
+			}
+			merged, err := mergeColumns(cols[j], c)
+			if err != nil {
+				return nil, fmt.Errorf("file %d: %s", i, err)
+			}
+			cols[j] = merged
+		}
+	}
+
+	m := &MergedSchema{Missing: make([][]string, len(footers))}
+	for i := range footers {
+		for j := range cols {
+			if !seen[i][cols[j].Name] {
+				cols[j].Optional = true
+				m.Missing[i] = append(m.Missing[i], cols[j].Name)
+			}
+		}
+	}
+	codec := sh.CompressionCodec_SNAPPY
+	if len(footers) > 0 {
+		codec = footerCodec(footers[0])
+	}
+	var err error
+	if m.Schema, err = NewSchemaFromColumns(cols, codec); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// mergeColumns returns the column whose values are the values of a and b.
+func mergeColumns(a, b SchemaColumn) (SchemaColumn, error) {
+	c := a
+	c.Optional = a.Optional || b.Optional
+	switch {
+	case a.Type == sh.Type_BYTE_ARRAY && b.Type == sh.Type_BYTE_ARRAY:
+		c.Binary = a.Binary || b.Binary
+		c.Enum = a.Enum && b.Enum && !c.Binary
+		return c, nil
+	case promotable(b, a):
+		return c, nil
+	case promotable(a, b):
+		b.Optional = c.Optional
+		return b, nil
+	}
+	ai, aok := intRange(a)
+	bi, bok := intRange(b)
+	if aok && bok {
+		// one is signed, the other unsigned and at least as wide.
+		if ai.IsSigned {
+			ai, bi = bi, ai
+		}
+		if ai.BitWidth < 64 {
+			it := sh.IntType{BitWidth: 2 * ai.BitWidth, IsSigned: true}
+			if bi.BitWidth > it.BitWidth {
+				it.BitWidth = bi.BitWidth
+			}
+			c.Type, c.Int = intPhysicalType(&it), &it
+			if it.BitWidth >= 32 {
+				c.Int = nil
+			}
+			return c, nil
+		}
+	}
+	if (aok || a.Type == sh.Type_FLOAT || a.Type == sh.Type_DOUBLE) && (bok || b.Type == sh.Type_FLOAT || b.Type == sh.Type_DOUBLE) {
+		c.Type, c.Int = sh.Type_DOUBLE, nil
+		return c, nil
+	}
+	return c, fmt.Errorf("column %s has incompatible types %s and %s", a.Name, typeName(a), typeName(b))
+}
+
+// footerCodec returns the codec of the first column chunk of a file,
+// snappy if it has none.
+func footerCodec(footer *sh.FileMetaData) sh.CompressionCodec {
+	if len(footer.RowGroups) > 0 && len(footer.RowGroups[0].Columns) > 0 {
+		return footer.RowGroups[0].Columns[0].MetaData.Codec
+	}
+	return sh.CompressionCodec_SNAPPY
+}
@@ -0,0 +1,262 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// DatasetReaderWorkers decodes the row groups of the files with n
+// goroutines, Read and ReadBatch then return the records of the decoded
+// row groups.  With 1, the default, they decode them themselves.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderWorkers(n int) func(*DatasetReader) {
+	return func(p *DatasetReader) { p.workers = n }
+}
+
+// DatasetReaderUnordered returns the records of the row groups in the
+// order the workers finish decoding them, rather than in the order of
+// the files and their row groups.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderUnordered() func(*DatasetReader) {
+	return func(p *DatasetReader) { p.unordered = true }
+}
+
+// DatasetReaderMemory bounds the uncompressed size of the row groups that
+// are decoded and not yet read to bytes, a row group that is larger is
+// decoded once no other one is.  Without it, the workers decode up to
+// twice as many row groups as there are workers ahead of Read.
+// It is an optional arg to NewDatasetReader
+func DatasetReaderMemory(bytes int64) func(*DatasetReader) {
+	return func(p *DatasetReader) { p.memory = bytes }
+}
+
+// rowGroupTask is a row group to decode with its own reader.
+type rowGroupTask struct {
+	file   *datasetFile
+	reader *ParquetReader
+	err    error // creating the reader failed
+	size   int64
+	done   chan rowGroupResult // nil when the results are unordered
+}
+
+type rowGroupResult struct {
+	reader *ParquetReader // nil if the row group cannot match the filter
+	size   int64
+	err    error
+}
+
+// parallelReader decodes the row groups of a dataset with workers.  The
+// row groups are planned in order by one goroutine, which takes their
+// size from the memory budget before a worker decodes them, so the row
+// group Read waits for has always been planned.
+type parallelReader struct {
+	budget  *memoryBudget
+	tasks   chan *rowGroupTask
+	ordered chan chan rowGroupResult // the results in the order of the tasks
+	results chan rowGroupResult      // the results in completion order
+	stopped chan struct{}
+	wg      sync.WaitGroup
+	sizes   map[*ParquetReader]int64 // row groups being read
+}
+
+// nextRowGroup sets the reader of the next decoded row group, starting
+// the workers on the first call.
+func (p *DatasetReader) nextRowGroup() error {
+	if p.parallel == nil {
+		p.parallel = p.startWorkers()
+	}
+	for p.reader == nil {
+		var res rowGroupResult
+		var ok bool
+		if p.unordered {
+			res, ok = <-p.parallel.results
+		} else {
+			var done chan rowGroupResult
+			if done, ok = <-p.parallel.ordered; ok {
+				res = <-done
+			}
+		}
+		if !ok {
+			return io.EOF
+		}
+		if res.err != nil {
+			p.parallel.budget.release(res.size)
+			return res.err
+		}
+		if res.reader == nil {
+			p.parallel.budget.release(res.size)
+			continue
+		}
+		p.parallel.sizes[res.reader] = res.size
+		p.reader = res.reader
+	}
+	return nil
+}
+
+func (p *DatasetReader) startWorkers() *parallelReader {
+	pr := &parallelReader{
+		budget:  newMemoryBudget(p.memory),
+		tasks:   make(chan *rowGroupTask),
+		ordered: make(chan chan rowGroupResult, p.workers),
+		results: make(chan rowGroupResult, p.workers),
+		stopped: make(chan struct{}),
+		sizes:   map[*ParquetReader]int64{},
+	}
+	pr.wg.Add(1)
+	go p.plan(pr)
+	var workers sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		pr.wg.Add(1)
+		workers.Add(1)
+		go func() {
+			defer pr.wg.Done()
+			defer workers.Done()
+			for t := range pr.tasks {
+				res := decodeRowGroup(t)
+				if t.done != nil {
+					t.done <- res
+					continue
+				}
+				select {
+				case pr.results <- res:
+				case <-pr.stopped:
+				}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(pr.results)
+	}()
+	return pr
+}
+
+// plan creates the tasks of the row groups of the files whose partitions
+// may match the filter, in order, as long as the budget allows.
+func (p *DatasetReader) plan(pr *parallelReader) {
+	defer pr.wg.Done()
+	defer close(pr.tasks)
+	defer close(pr.ordered)
+	for i := range p.files {
+		df := &p.files[i]
+		if p.skip(df) {
+			continue
+		}
+		for j := range df.footer.RowGroups {
+			t := &rowGroupTask{file: df}
+			if !p.unordered {
+				t.done = make(chan rowGroupResult, 1)
+			}
+			opts := append(p.readerOptions(df), func(r *ParquetReader) { r.rowGroup, r.end = j, j+1 })
+			t.reader, t.err = newParquetReader(nil, df.footer, opts...)
+			if t.err == nil {
+				t.size = t.reader.decodedSize(df.footer.RowGroups[j])
+			}
+			if !pr.budget.acquire(t.size) {
+				return
+			}
+			if t.done != nil {
+				select {
+				case pr.ordered <- t.done:
+				case <-pr.stopped:
+					return
+				}
+			}
+			select {
+			case pr.tasks <- t:
+			case <-pr.stopped:
+				return
+			}
+			if t.err != nil {
+				return
+			}
+		}
+	}
+}
+
+// decodeRowGroup decodes the row group of the task, its file is only
+// read while it is decoded.
+func decodeRowGroup(t *rowGroupTask) rowGroupResult {
+	res := rowGroupResult{size: t.size}
+	if t.err != nil {
+		res.err = fmt.Errorf("%s: %s", t.file.path, t.err)
+		return res
+	}
+	f, err := os.Open(t.file.path)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer f.Close()
+	t.reader.r = f
+	switch err := t.reader.nextRowGroup(); err {
+	case nil:
+		res.reader = t.reader
+	case io.EOF:
+	default:
+		res.err = fmt.Errorf("%s: %s", t.file.path, err)
+	}
+	t.reader.r = nil
+	return res
+}
+
+// release returns the memory of a row group whose records have been read.
+func (pr *parallelReader) release(r *ParquetReader) {
+	pr.budget.release(pr.sizes[r])
+	delete(pr.sizes, r)
+}
+
+// stop stops the workers and waits for them.
+func (pr *parallelReader) stop() {
+	close(pr.stopped)
+	pr.budget.close()
+	pr.wg.Wait()
+}
+
+// memoryBudget bounds the bytes taken by the decoded row groups.
+type memoryBudget struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int64 // no limit when 0
+	used   int64
+	peak   int64 // the most bytes used at once
+	closed bool
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	b := &memoryBudget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire waits until n bytes are available, or nothing is in use, and
+// takes them.  It returns false if the budget was closed.
+func (b *memoryBudget) acquire(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for !b.closed && b.limit > 0 && b.used > 0 && b.used+n > b.limit {
+		b.cond.Wait()
+	}
+	b.used += n
+	if b.used > b.peak {
+		b.peak = b.used
+	}
+	return !b.closed
+}
+
+func (b *memoryBudget) release(n int64) {
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// close wakes up and fails the goroutines waiting for memory.
+func (b *memoryBudget) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
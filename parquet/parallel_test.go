@@ -0,0 +1,76 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+func Test_memoryBudget(t *testing.T) {
+	sc, err := NewSchema(`{"fields": [{"name": "id", "type": "int"}, {"name": "name", "type": "string"}, {"name": "day", "type": "int"}]}`, sh.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ioutil.TempDir("", "dataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	pw, err := NewPartitionedWriter(sc, []string{"day"}, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		if err := pw.WriteJson([]byte(fmt.Sprintf(`{"id": %d, "name": "name-%d", "day": %d}`, i, i, i%4))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dr, err := NewDatasetReader(root, DatasetReaderWorkers(4), DatasetReaderMemory(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var largest int64
+	var groups int
+	for _, f := range dr.files {
+		for _, rg := range f.footer.RowGroups {
+			var size int64
+			for _, ch := range rg.Columns {
+				size += ch.MetaData.TotalUncompressedSize
+			}
+			if size > largest {
+				largest = size
+			}
+			groups++
+		}
+	}
+	var rows int
+	for {
+		var record map[string]interface{}
+		err := dr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows++
+	}
+	peak := dr.parallel.budget.peak
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 200 || groups < 8 {
+		t.Fatalf("read %d rows of %d row groups", rows, groups)
+	}
+	if peak <= 0 || peak > largest {
+		t.Fatalf("%d bytes in use at once, the largest row group has %d", peak, largest)
+	}
+}
@@ -75,11 +75,10 @@ func (s schema) schema() (int64, []*sch.SchemaElement) {
 		}
 
 		se := &sch.SchemaElement{
-			Name:       f.Path[len(f.Path)-1],
-			TypeLength: &z,
-			Scale:      &z,
-			Precision:  &z,
-			FieldID:    &z,
+			Name:      f.Path[len(f.Path)-1],
+			Scale:     &z,
+			Precision: &z,
+			FieldID:   &z,
 		}
 
 		f.Type(se)
@@ -102,6 +101,13 @@ type Metadata struct {
 	rowGroupDocs int64
 	rowGroups    []RowGroup
 
+	// row groups of the file that is appended to and where they end
+	prevRowGroups []*sch.RowGroup
+	prevMetadata  *sch.FileMetaData
+	offset        int64
+
+	sortingColumns []*sch.SortingColumn
+
 	metadata *sch.FileMetaData
 }
 
@@ -121,6 +127,7 @@ func New(fields ...Field) *Metadata {
 	m := &Metadata{
 		ts:     ts,
 		schema: schemaElements(fields),
+		offset: 4,
 	}
 
 	m.StartRowGroup(fields...)
@@ -131,8 +138,9 @@ func New(fields ...Field) *Metadata {
 func (m *Metadata) StartRowGroup(fields ...Field) {
 	m.rowGroupDocs = 0
 	m.rowGroups = append(m.rowGroups, RowGroup{
-		fields:  schemaElements(fields),
-		columns: make(map[string]sch.ColumnChunk),
+		fields:   schemaElements(fields),
+		columns:  make(map[string]sch.ColumnChunk),
+		rowGroup: sch.RowGroup{SortingColumns: m.sortingColumns},
 	})
 }
 
@@ -145,6 +153,31 @@ func (m *Metadata) NextDoc() {
 	m.pageDocs++
 }
 
+// NextDocs is NextDoc for n documents at once.
+func (m *Metadata) NextDocs(n int64) {
+	m.docs += n
+	m.rowGroupDocs += n
+	m.pageDocs += n
+}
+
+// Resume makes the Metadata continue the file described by footer, whose
+// data ends at offset.  The footer's row groups are kept as they are and the
+// row groups written afterwards are added to them.
+func (m *Metadata) Resume(footer *sch.FileMetaData, offset int64) {
+	m.prevMetadata = footer
+	m.prevRowGroups = footer.RowGroups
+	m.docs += footer.NumRows
+	m.offset = offset
+}
+
+// SortingColumns marks the row groups written from now on as sorted by cols.
+func (m *Metadata) SortingColumns(cols []*sch.SortingColumn) {
+	m.sortingColumns = cols
+	if i := len(m.rowGroups); i > 0 {
+		m.rowGroups[i-1].rowGroup.SortingColumns = cols
+	}
+}
+
 // RowGroups returns a summary of each schema.RowGroup
 func (m *Metadata) RowGroups() []RowGroup {
 	rgs := make([]RowGroup, len(m.metadata.RowGroups))
@@ -159,6 +192,12 @@ func (m *Metadata) RowGroups() []RowGroup {
 
 // WritePageHeader is called in order to finish writing to a column chunk.
 func (m *Metadata) WritePageHeader(w io.Writer, pth []string, dataLen, compressedLen, defCount, count int, defLen, repLen int64, comp sch.CompressionCodec, stats Stats) error {
+	return m.writePageHeader(w, pth, sch.Encoding_PLAIN, dataLen, compressedLen, defCount, count, defLen, repLen, comp, stats)
+}
+
+// writePageHeader is WritePageHeader for a page whose values have the
+// encoding enc.
+func (m *Metadata) writePageHeader(w io.Writer, pth []string, enc sch.Encoding, dataLen, compressedLen, defCount, count int, defLen, repLen int64, comp sch.CompressionCodec, stats Stats) error {
 	var sts *sch.Statistics
 	if stats != nil {
 		sts = &sch.Statistics{
@@ -176,7 +215,7 @@ func (m *Metadata) WritePageHeader(w io.Writer, pth []string, dataLen, compresse
 		CompressedPageSize:   int32(compressedLen),
 		DataPageHeader: &sch.DataPageHeader{
 			NumValues:               int32(count),
-			Encoding:                sch.Encoding_PLAIN,
+			Encoding:                enc,
 			DefinitionLevelEncoding: sch.Encoding_RLE,
 			RepetitionLevelEncoding: sch.Encoding_RLE,
 			Statistics:              sts,
@@ -190,7 +229,7 @@ func (m *Metadata) WritePageHeader(w io.Writer, pth []string, dataLen, compresse
 		return err
 	}
 
-	if err := m.updateRowGroup(pth, dataLen, compressedLen, len(buf), count, comp); err != nil {
+	if err := m.updateRowGroup(pth, enc, dataLen, compressedLen, len(buf), count, comp, sts); err != nil {
 		return err
 	}
 
@@ -198,7 +237,35 @@ func (m *Metadata) WritePageHeader(w io.Writer, pth []string, dataLen, compresse
 	return err
 }
 
-func (m *Metadata) updateRowGroup(pth []string, dataLen, compressedLen, headerLen, count int, comp sch.CompressionCodec) error {
+// writeDictionaryPageHeader writes the header of the dictionary page of a
+// column chunk, which holds count PLAIN encoded values.  It must come
+// before the data pages of the column chunk.
+func (m *Metadata) writeDictionaryPageHeader(w io.Writer, pth []string, dataLen, compressedLen, count int, comp sch.CompressionCodec) error {
+	ph := &sch.PageHeader{
+		Type:                 sch.PageType_DICTIONARY_PAGE,
+		UncompressedPageSize: int32(dataLen),
+		CompressedPageSize:   int32(compressedLen),
+		DictionaryPageHeader: &sch.DictionaryPageHeader{
+			NumValues: int32(count),
+			Encoding:  sch.Encoding_PLAIN,
+		},
+	}
+	buf, err := m.ts.Write(context.TODO(), ph)
+	if err != nil {
+		return err
+	}
+	i := len(m.rowGroups)
+	if i == 0 {
+		return fmt.Errorf("no row groups, you must call StartRowGroup at least once")
+	}
+	if err := m.rowGroups[i-1].addDictionaryPage(pth, dataLen+len(buf), compressedLen+len(buf), m.schema, comp); err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func (m *Metadata) updateRowGroup(pth []string, enc sch.Encoding, dataLen, compressedLen, headerLen, count int, comp sch.CompressionCodec, sts *sch.Statistics) error {
 	i := len(m.rowGroups)
 	if i == 0 {
 		return fmt.Errorf("no row groups, you must call StartRowGroup at least once")
@@ -207,7 +274,7 @@ func (m *Metadata) updateRowGroup(pth []string, dataLen, compressedLen, headerLe
 	rg := m.rowGroups[i-1]
 
 	rg.rowGroup.NumRows = m.rowGroupDocs
-	err := rg.updateColumnChunk(pth, dataLen+headerLen, compressedLen+headerLen, count, m.schema, comp)
+	err := rg.updateColumnChunk(pth, enc, dataLen+headerLen, compressedLen+headerLen, count, m.schema, comp, sts)
 	m.rowGroups[i-1] = rg
 	return err
 }
@@ -226,17 +293,23 @@ func (m *Metadata) Rows() int64 {
 	return m.metadata.NumRows
 }
 
-// Footer writes the FileMetaData at the end of the file.
+// Footer writes the FileMetaData at the end of the file.  The FileMetaData
+// is kept, so RowGroups, Rows and Pages can be used once it is written.
 func (m *Metadata) Footer(w io.Writer) error {
 	_, s := m.schema.schema()
 	fmd := &sch.FileMetaData{
 		Version:   1,
 		Schema:    s,
 		NumRows:   m.docs,
-		RowGroups: make([]*sch.RowGroup, 0, len(m.rowGroups)),
+		RowGroups: make([]*sch.RowGroup, 0, len(m.prevRowGroups)+len(m.rowGroups)),
+	}
+	if m.prevMetadata != nil {
+		fmd.KeyValueMetadata = m.prevMetadata.KeyValueMetadata
+		fmd.CreatedBy = m.prevMetadata.CreatedBy
+		fmd.RowGroups = append(fmd.RowGroups, m.prevRowGroups...)
 	}
 
-	pos := int64(4)
+	pos := m.offset
 	for _, mrg := range m.rowGroups {
 		rg := mrg.rowGroup
 		if rg.NumRows == 0 {
@@ -249,8 +322,13 @@ func (m *Metadata) Footer(w io.Writer) error {
 				continue
 			}
 
+			// the page offsets are relative to the column chunk until now
 			ch.FileOffset = pos
-			ch.MetaData.DataPageOffset = pos
+			ch.MetaData.DataPageOffset += pos
+			if ch.MetaData.DictionaryPageOffset != nil {
+				o := *ch.MetaData.DictionaryPageOffset + pos
+				ch.MetaData.DictionaryPageOffset = &o
+			}
 			rg.TotalByteSize += ch.MetaData.TotalCompressedSize
 			rg.Columns = append(rg.Columns, &ch)
 			pos += ch.MetaData.TotalCompressedSize
@@ -259,7 +337,29 @@ func (m *Metadata) Footer(w io.Writer) error {
 		fmd.RowGroups = append(fmd.RowGroups, &rg)
 	}
 
-	buf, err := m.ts.Write(context.TODO(), fmd)
+	// the statistics use the order of the logical types, such as unsigned
+	// for unsigned integers.  Every leaf needs an entry, INT96 ones too,
+	// their order is undefined but they have no min and max.
+	for _, se := range s {
+		if se.Type != nil {
+			fmd.ColumnOrders = append(fmd.ColumnOrders, &sch.ColumnOrder{TYPE_ORDER: &sch.TypeDefinedOrder{}})
+		}
+	}
+
+	m.metadata = fmd
+	return writeFooter(m.ts, w, fmd)
+}
+
+// WriteFooter writes fmd and its length, everything but the trailing
+// magic bytes of a parquet file.
+func WriteFooter(w io.Writer, fmd *sch.FileMetaData) error {
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+	return writeFooter(ts, w, fmd)
+}
+
+func writeFooter(ts *thrift.TSerializer, w io.Writer, fmd *sch.FileMetaData) error {
+	buf, err := ts.Write(context.TODO(), fmd)
 	if err != nil {
 		return err
 	}
@@ -289,7 +389,7 @@ func (r *RowGroup) Columns() []*sch.ColumnChunk {
 	return r.rowGroup.Columns
 }
 
-func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count int, fields schema, comp sch.CompressionCodec) error {
+func (r *RowGroup) updateColumnChunk(pth []string, enc sch.Encoding, dataLen, compressedLen, count int, fields schema, comp sch.CompressionCodec, sts *sch.Statistics) error {
 	col := strings.Join(pth, ".")
 
 	ch, ok := r.columns[col]
@@ -302,11 +402,34 @@ func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count
 		ch = sch.ColumnChunk{
 			MetaData: &sch.ColumnMetaData{
 				Type:         t,
-				Encodings:    []sch.Encoding{sch.Encoding_PLAIN},
+				Encodings:    []sch.Encoding{enc},
 				PathInSchema: pth,
 				Codec:        comp,
 			},
 		}
+		if sts != nil {
+			cs := *sts
+			ch.MetaData.Statistics = &cs
+		}
+	} else if ch.MetaData.NumValues == 0 {
+		// only the dictionary page has been written
+		if sts != nil {
+			cs := *sts
+			ch.MetaData.Statistics = &cs
+		}
+		if !hasEncoding(ch.MetaData.Encodings, enc) {
+			ch.MetaData.Encodings = append(ch.MetaData.Encodings, enc)
+		}
+	} else {
+		se := fields.lookup[col]
+		ch.MetaData.Statistics = mergeStatistics(&se, ch.MetaData.Statistics, sts)
+		if !hasEncoding(ch.MetaData.Encodings, enc) {
+			ch.MetaData.Encodings = append(ch.MetaData.Encodings, enc)
+		}
+	}
+	// the definition levels of optional columns are RLE encoded.
+	if se := fields.lookup[col]; se.GetRepetitionType() != sch.FieldRepetitionType_REQUIRED && !hasEncoding(ch.MetaData.Encodings, sch.Encoding_RLE) {
+		ch.MetaData.Encodings = append(ch.MetaData.Encodings, sch.Encoding_RLE)
 	}
 
 	ch.MetaData.NumValues += int64(count)
@@ -316,16 +439,51 @@ func (r *RowGroup) updateColumnChunk(pth []string, dataLen, compressedLen, count
 	return nil
 }
 
+// addDictionaryPage starts the column chunk with a dictionary page of
+// dataLen bytes, the data pages follow it.
+func (r *RowGroup) addDictionaryPage(pth []string, dataLen, compressedLen int, fields schema, comp sch.CompressionCodec) error {
+	col := strings.Join(pth, ".")
+	if _, ok := r.columns[col]; ok {
+		return fmt.Errorf("column %s: the dictionary page must be the first page", col)
+	}
+	t, err := columnType(col, fields)
+	if err != nil {
+		return err
+	}
+	var dictOffset int64
+	r.columns[col] = sch.ColumnChunk{
+		MetaData: &sch.ColumnMetaData{
+			Type:                  t,
+			Encodings:             []sch.Encoding{sch.Encoding_PLAIN},
+			PathInSchema:          pth,
+			Codec:                 comp,
+			DictionaryPageOffset:  &dictOffset,
+			DataPageOffset:        int64(compressedLen),
+			TotalUncompressedSize: int64(dataLen),
+			TotalCompressedSize:   int64(compressedLen),
+		},
+	}
+	return nil
+}
+
+func hasEncoding(encs []sch.Encoding, enc sch.Encoding) bool {
+	for _, e := range encs {
+		if e == enc {
+			return true
+		}
+	}
+	return false
+}
+
 func schemaElements(fields []Field) schema {
 	m := make(map[string]sch.SchemaElement)
 	for _, f := range fields {
 		var z int32
 		se := sch.SchemaElement{
-			Name:       f.Name,
-			TypeLength: &z,
-			Scale:      &z,
-			Precision:  &z,
-			FieldID:    &z,
+			Name:      f.Name,
+			Scale:     &z,
+			Precision: &z,
+			FieldID:   &z,
 		}
 
 		f.Type(&se)
@@ -401,7 +559,7 @@ func PageHeaders(footer *sch.FileMetaData, r io.ReadSeeker) ([]sch.PageHeader, e
 	var pageHeaders []sch.PageHeader
 	for _, rg := range footer.RowGroups {
 		for _, col := range rg.Columns {
-			h, err := PageHeadersAtOffset(r, col.MetaData.DataPageOffset, col.MetaData.NumValues)
+			h, err := PageHeadersAtOffset(r, chunkOffset(col.MetaData), col.MetaData.NumValues)
 			if err != nil {
 				return nil, err
 			}
@@ -441,11 +599,35 @@ func PageHeadersAtOffset(r io.ReadSeeker, o, n int64) ([]sch.PageHeader, error)
 			return nil, fmt.Errorf("unable to seek to next page: %s", err)
 		}
 
-		nRead += int64(ph.DataPageHeader.NumValues)
+		nRead += pageValues(ph)
 	}
 	return out, nil
 }
 
+// chunkOffset returns where the first page of a column chunk starts.
+func chunkOffset(md *sch.ColumnMetaData) int64 {
+	o := md.DataPageOffset
+	if md.DictionaryPageOffset != nil && *md.DictionaryPageOffset > 0 && *md.DictionaryPageOffset < o {
+		o = *md.DictionaryPageOffset
+	}
+	if md.IndexPageOffset != nil && *md.IndexPageOffset > 0 && *md.IndexPageOffset < o {
+		o = *md.IndexPageOffset
+	}
+	return o
+}
+
+// pageValues returns the number of values in a data page, 0 for
+// dictionary and index pages.
+func pageValues(ph *sch.PageHeader) int64 {
+	switch {
+	case ph.DataPageHeader != nil:
+		return int64(ph.DataPageHeader.NumValues)
+	case ph.DataPageHeaderV2 != nil:
+		return int64(ph.DataPageHeaderV2.NumValues)
+	}
+	return 0
+}
+
 // RepetitionRequired sets the repetition type to required
 func RepetitionRequired(se *sch.SchemaElement) {
 	t := sch.FieldRepetitionType_REQUIRED
@@ -466,7 +648,9 @@ func RepetitionRepeated(se *sch.SchemaElement) {
 
 var fieldFuncs = []FieldFunc{RepetitionRequired, RepetitionOptional, RepetitionRepeated}
 
-// GetBools reads a byte array and turns each bit into a bool
+// GetBools reads a byte array and turns each bit into a bool.  DoRead
+// turns RLE encoded pages into such byte arrays, so both encodings of
+// boolean columns can be read.
 func GetBools(r io.Reader, n int, pageSizes []int) ([]bool, error) {
 	var vals [8]bool
 	data, _ := ioutil.ReadAll(r)
@@ -0,0 +1,8 @@
+module github.com/houkx/parquet-go/parquet/pqarrow
+
+go 1.14
+
+require (
+	github.com/apache/arrow/go/arrow v0.0.0-20211112161151-bc219186db40
+	github.com/houkx/parquet-go v0.0.0-20261019164249-dfe806428226
+)
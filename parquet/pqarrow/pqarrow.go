@@ -0,0 +1,247 @@
+// Package pqarrow converts between Arrow records and parquet files.  The
+// records are written and read through the columnar Batch API of package
+// parquet, column by column, without going through maps.  It is a module
+// of its own, so that only its users depend on Arrow.
+package pqarrow
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
+	park "github.com/houkx/parquet-go/parquet"
+	sh "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// ToSchema converts an Arrow schema into a parquet Schema, nullable
+// fields become optional columns.
+func ToSchema(s *arrow.Schema, compression sh.CompressionCodec) (*park.Schema, error) {
+	cols := make([]park.SchemaColumn, len(s.Fields()))
+	for i, f := range s.Fields() {
+		t, ok := parquetType(f.Type)
+		if !ok {
+			return nil, fmt.Errorf("field %s has unsupported arrow type %s", f.Name, f.Type)
+		}
+		cols[i] = park.SchemaColumn{Name: f.Name, Type: t, Optional: f.Nullable, Binary: f.Type.ID() == arrow.BINARY}
+	}
+	return park.NewSchemaFromColumns(cols, compression)
+}
+
+// FromSchema converts a parquet Schema into an Arrow schema.
+func FromSchema(s *park.Schema) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(s.Fields))
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		t, ok := arrowType(f.Type())
+		if it := f.IntType(); !ok || it != nil && (!it.IsSigned || it.BitWidth < 32) {
+			return nil, fmt.Errorf("column %s has unsupported type %s", f.Name(), f.Type())
+		}
+		if f.Binary() {
+			t = arrow.BinaryTypes.Binary
+		}
+		fields[i] = arrow.Field{Name: f.Name(), Type: t, Nullable: f.Optional()}
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func parquetType(t arrow.DataType) (sh.Type, bool) {
+	switch t.ID() {
+	case arrow.BOOL:
+		return sh.Type_BOOLEAN, true
+	case arrow.INT32:
+		return sh.Type_INT32, true
+	case arrow.INT64:
+		return sh.Type_INT64, true
+	case arrow.FLOAT32:
+		return sh.Type_FLOAT, true
+	case arrow.FLOAT64:
+		return sh.Type_DOUBLE, true
+	case arrow.STRING, arrow.BINARY:
+		return sh.Type_BYTE_ARRAY, true
+	}
+	return 0, false
+}
+
+func arrowType(t sh.Type) (arrow.DataType, bool) {
+	switch t {
+	case sh.Type_BOOLEAN:
+		return arrow.FixedWidthTypes.Boolean, true
+	case sh.Type_INT32:
+		return arrow.PrimitiveTypes.Int32, true
+	case sh.Type_INT64:
+		return arrow.PrimitiveTypes.Int64, true
+	case sh.Type_FLOAT:
+		return arrow.PrimitiveTypes.Float32, true
+	case sh.Type_DOUBLE:
+		return arrow.PrimitiveTypes.Float64, true
+	case sh.Type_BYTE_ARRAY:
+		return arrow.BinaryTypes.String, true
+	}
+	return nil, false
+}
+
+// WriteRecord writes the rows of rec with pw, the columns of rec are
+// matched with the columns of the writer's schema by name.
+func WriteRecord(pw *park.ParquetWriter, rec array.Record) error {
+	batch := &park.Batch{Rows: int(rec.NumRows()), Columns: make([]park.ColumnBatch, rec.NumCols())}
+	for i, col := range rec.Columns() {
+		c := &batch.Columns[i]
+		c.Name = rec.ColumnName(i)
+		if err := fromArray(c, col); err != nil {
+			return fmt.Errorf("column %s: %s", c.Name, err)
+		}
+	}
+	return pw.WriteBatch(batch)
+}
+
+// fromArray sets the values and nulls of c to the ones of a, the numeric
+// values are shared with a.
+func fromArray(c *park.ColumnBatch, a array.Interface) error {
+	switch a := a.(type) {
+	case *array.Boolean:
+		c.Type = sh.Type_BOOLEAN
+		c.Bools = make([]bool, a.Len())
+		for i := range c.Bools {
+			c.Bools[i] = a.Value(i)
+		}
+	case *array.Int32:
+		c.Type, c.Int32s = sh.Type_INT32, a.Int32Values()
+	case *array.Int64:
+		c.Type, c.Int64s = sh.Type_INT64, a.Int64Values()
+	case *array.Float32:
+		c.Type, c.Float32s = sh.Type_FLOAT, a.Float32Values()
+	case *array.Float64:
+		c.Type, c.Float64s = sh.Type_DOUBLE, a.Float64Values()
+	case *array.String:
+		c.Type = sh.Type_BYTE_ARRAY
+		c.Strings = make([]string, a.Len())
+		for i := range c.Strings {
+			c.Strings[i] = a.Value(i)
+		}
+	case *array.Binary:
+		c.Type, c.Binary = sh.Type_BYTE_ARRAY, true
+		c.Strings = make([]string, a.Len())
+		for i := range c.Strings {
+			c.Strings[i] = string(a.Value(i))
+		}
+	default:
+		return fmt.Errorf("unsupported arrow type %s", a.DataType())
+	}
+	if a.NullN() > 0 {
+		c.Nulls = make([]bool, a.Len())
+		for i := range c.Nulls {
+			c.Nulls[i] = a.IsNull(i)
+		}
+	}
+	return nil
+}
+
+// RecordReader reads the batches of a ParquetReader as Arrow records,
+// the nulls of optional columns are set in the validity bitmaps.
+type RecordReader struct {
+	pr     *park.ParquetReader
+	mem    memory.Allocator
+	schema *arrow.Schema
+	batch  park.Batch
+}
+
+// NewRecordReader reads the records of pr, whose batch size is the number
+// of rows of the records.  The records have the columns selected by
+// ParquetReaderColumns, pr must be opened with ParquetReaderBinary for the
+// binary columns to be read as Arrow binary rather than string columns.
+func NewRecordReader(pr *park.ParquetReader, mem memory.Allocator) (*RecordReader, error) {
+	s, err := FromSchema(pr.Schema())
+	if err != nil {
+		return nil, err
+	}
+	var fields []arrow.Field
+	for _, name := range pr.Columns() {
+		idx := s.FieldIndices(name)
+		if len(idx) == 0 {
+			return nil, fmt.Errorf("column %s is not in the schema", name)
+		}
+		fields = append(fields, s.Field(idx[0]))
+	}
+	return &RecordReader{pr: pr, mem: mem, schema: arrow.NewSchema(fields, nil)}, nil
+}
+
+// Read returns the next record, which must be released by the caller.
+// It returns io.EOF once all rows have been read.
+func (r *RecordReader) Read() (array.Record, error) {
+	if err := r.pr.ReadBatch(&r.batch); err != nil {
+		return nil, err
+	}
+	cols := make([]array.Interface, len(r.batch.Columns))
+	defer func() {
+		for _, c := range cols {
+			if c != nil {
+				c.Release()
+			}
+		}
+	}()
+	for i := range r.batch.Columns {
+		cols[i] = r.toArray(&r.batch.Columns[i])
+	}
+	return array.NewRecord(r.schema, cols, int64(r.batch.Rows)), nil
+}
+
+// toArray copies the values of c into a new array.
+func (r *RecordReader) toArray(c *park.ColumnBatch) array.Interface {
+	var valid []bool
+	if c.Nulls != nil {
+		valid = make([]bool, len(c.Nulls))
+		for i, null := range c.Nulls {
+			valid[i] = !null
+		}
+	}
+	switch c.Type {
+	case sh.Type_BOOLEAN:
+		b := array.NewBooleanBuilder(r.mem)
+		defer b.Release()
+		b.AppendValues(c.Bools, valid)
+		return b.NewArray()
+	case sh.Type_INT32:
+		b := array.NewInt32Builder(r.mem)
+		defer b.Release()
+		b.AppendValues(c.Int32s, valid)
+		return b.NewArray()
+	case sh.Type_INT64:
+		b := array.NewInt64Builder(r.mem)
+		defer b.Release()
+		b.AppendValues(c.Int64s, valid)
+		return b.NewArray()
+	case sh.Type_FLOAT:
+		b := array.NewFloat32Builder(r.mem)
+		defer b.Release()
+		b.AppendValues(c.Float32s, valid)
+		return b.NewArray()
+	case sh.Type_DOUBLE:
+		b := array.NewFloat64Builder(r.mem)
+		defer b.Release()
+		b.AppendValues(c.Float64s, valid)
+		return b.NewArray()
+	case sh.Type_BYTE_ARRAY:
+		if c.Binary {
+			b := array.NewBinaryBuilder(r.mem, arrow.BinaryTypes.Binary)
+			defer b.Release()
+			vals := make([][]byte, len(c.Strings))
+			for i, s := range c.Strings {
+				vals[i] = []byte(s)
+			}
+			b.AppendValues(vals, valid)
+			return b.NewArray()
+		}
+		fallthrough
+	default:
+		b := array.NewStringBuilder(r.mem)
+		defer b.Release()
+		b.AppendValues(c.Strings, valid)
+		return b.NewArray()
+	}
+}
+
+// Schema returns the schema of the records.
+func (r *RecordReader) Schema() *arrow.Schema {
+	return r.schema
+}
@@ -0,0 +1,98 @@
+package pqarrow_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/pqarrow"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+type memFile struct {
+	bytes.Buffer
+}
+
+func (m *memFile) Close() error { return nil }
+
+func Test_arrowRoundTrip(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	as := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "score", Type: arrow.PrimitiveTypes.Float32, Nullable: true},
+		{Name: "ok", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "blob", Type: arrow.BinaryTypes.Binary},
+	}, nil)
+	sc, err := pqarrow.ToSchema(as, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := array.NewRecordBuilder(mem, as)
+	defer b.Release()
+	for i := 0; i < 30; i++ {
+		b.Field(0).(*array.Int64Builder).Append(int64(i))
+		if i%4 == 0 {
+			b.Field(1).AppendNull()
+		} else {
+			b.Field(1).(*array.StringBuilder).Append(string(rune('a' + i)))
+		}
+		if i%5 == 0 {
+			b.Field(2).AppendNull()
+		} else {
+			b.Field(2).(*array.Float32Builder).Append(float32(i) / 2)
+		}
+		b.Field(3).(*array.BooleanBuilder).Append(i%2 == 0)
+		b.Field(4).(*array.BinaryBuilder).Append([]byte{0xff, byte(i)})
+	}
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	file := &memFile{}
+	pw := park.NewParquetWriter(sc, file, 7)
+	if err := pqarrow.WriteRecord(pw, rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := park.NewParquetReader(bytes.NewReader(file.Bytes()), park.ParquetReaderBatchSize(12), park.ParquetReaderBinary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pqarrow.NewRecordReader(pr, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rr.Schema().Equal(as) {
+		t.Fatalf("read schema %s", rr.Schema())
+	}
+	var rows int64
+	for {
+		out, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := rec.NewSlice(rows, rows+out.NumRows())
+		if !array.RecordEqual(out, expected) {
+			t.Fatalf("rows %d: %v", rows, out)
+		}
+		rows += out.NumRows()
+		expected.Release()
+		out.Release()
+	}
+	if rows != 30 {
+		t.Fatalf("read %d rows", rows)
+	}
+}
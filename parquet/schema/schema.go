@@ -351,6 +351,7 @@ const (
 	Encoding_DELTA_LENGTH_BYTE_ARRAY Encoding = 6
 	Encoding_DELTA_BYTE_ARRAY        Encoding = 7
 	Encoding_RLE_DICTIONARY          Encoding = 8
+	Encoding_BYTE_STREAM_SPLIT       Encoding = 9
 )
 
 func (p Encoding) String() string {
@@ -371,6 +372,8 @@ func (p Encoding) String() string {
 		return "DELTA_BYTE_ARRAY"
 	case Encoding_RLE_DICTIONARY:
 		return "RLE_DICTIONARY"
+	case Encoding_BYTE_STREAM_SPLIT:
+		return "BYTE_STREAM_SPLIT"
 	}
 	return "<UNSET>"
 }
@@ -393,6 +396,8 @@ func EncodingFromString(s string) (Encoding, error) {
 		return Encoding_DELTA_BYTE_ARRAY, nil
 	case "RLE_DICTIONARY":
 		return Encoding_RLE_DICTIONARY, nil
+	case "BYTE_STREAM_SPLIT":
+		return Encoding_BYTE_STREAM_SPLIT, nil
 	}
 	return Encoding(0), fmt.Errorf("not a valid Encoding string")
 }
@@ -0,0 +1,197 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	sch "github.com/houkx/parquet-go/parquet/schema"
+)
+
+// StatValue decodes a min or max value of a sch.Statistics.  The values
+// are PLAIN encoded, except that byte arrays have no length prefix.
+// Integers with a logical type have the Go type of their values, such as
+// uint64 for UINT_64 columns.
+func StatValue(se *sch.SchemaElement, b []byte) interface{} {
+	if b == nil || se.Type == nil {
+		return nil
+	}
+	switch *se.Type {
+	case sch.Type_INT32:
+		if len(b) < 4 {
+			return nil
+		}
+		v := int32(binary.LittleEndian.Uint32(b))
+		if it := intTypeOf(se); it != nil {
+			return intValue(it, int64(v))
+		}
+		return v
+	case sch.Type_INT64:
+		if len(b) < 8 {
+			return nil
+		}
+		v := int64(binary.LittleEndian.Uint64(b))
+		if it := intTypeOf(se); it != nil {
+			return intValue(it, v)
+		}
+		return v
+	case sch.Type_FLOAT:
+		if len(b) < 4 {
+			return nil
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case sch.Type_DOUBLE:
+		if len(b) < 8 {
+			return nil
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	case sch.Type_BYTE_ARRAY:
+		return string(b)
+	case sch.Type_FIXED_LEN_BYTE_ARRAY:
+		return b
+	case sch.Type_BOOLEAN:
+		if len(b) < 1 {
+			return nil
+		}
+		return b[0] != 0
+	}
+	return nil
+}
+
+// compareStatValues compares two encoded min or max values of the column se.
+func compareStatValues(se *sch.SchemaElement, a, b []byte) int {
+	if t := se.GetType(); se.Type != nil && (t == sch.Type_BYTE_ARRAY || t == sch.Type_FIXED_LEN_BYTE_ARRAY) {
+		return bytes.Compare(a, b)
+	}
+	return compareValues(StatValue(se, a), StatValue(se, b))
+}
+
+// compareValues compares two values of the same column type, nil sorts first.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch x := a.(type) {
+	case int8:
+		return compareInt64(int64(x), int64(b.(int8)))
+	case int16:
+		return compareInt64(int64(x), int64(b.(int16)))
+	case int32:
+		return compareInt64(int64(x), int64(b.(int32)))
+	case int64:
+		return compareInt64(x, b.(int64))
+	case uint8:
+		return compareUint64(uint64(x), uint64(b.(uint8)))
+	case uint16:
+		return compareUint64(uint64(x), uint64(b.(uint16)))
+	case uint32:
+		return compareUint64(uint64(x), uint64(b.(uint32)))
+	case uint64:
+		return compareUint64(x, b.(uint64))
+	case float32:
+		return compareFloat64(float64(x), float64(b.(float32)))
+	case float64:
+		return compareFloat64(x, b.(float64))
+	case string:
+		if y, ok := b.([]byte); ok {
+			return compareString(x, string(y))
+		}
+		return compareString(x, b.(string))
+	case []byte:
+		// the min and max of binary columns are strings
+		if y, ok := b.(string); ok {
+			return compareString(string(x), y)
+		}
+		return bytes.Compare(x, b.([]byte))
+	case time.Time:
+		y := b.(time.Time)
+		switch {
+		case x.Before(y):
+			return -1
+		case x.After(y):
+			return 1
+		}
+		return 0
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case y:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareString(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// mergeStatistics folds the statistics of a page into the statistics of
+// its column chunk.
+func mergeStatistics(se *sch.SchemaElement, chunk, page *sch.Statistics) *sch.Statistics {
+	if chunk == nil || page == nil {
+		return nil
+	}
+	if page.MinValue != nil && (chunk.MinValue == nil || compareStatValues(se, page.MinValue, chunk.MinValue) < 0) {
+		chunk.MinValue = page.MinValue
+	}
+	if page.MaxValue != nil && (chunk.MaxValue == nil || compareStatValues(se, page.MaxValue, chunk.MaxValue) > 0) {
+		chunk.MaxValue = page.MaxValue
+	}
+	if chunk.NullCount != nil && page.NullCount != nil {
+		n := *chunk.NullCount + *page.NullCount
+		chunk.NullCount = &n
+	} else {
+		chunk.NullCount = nil
+	}
+	chunk.DistinctCount = nil
+	return chunk
+}
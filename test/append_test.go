@@ -0,0 +1,134 @@
+package test
+
+import (
+	"bytes"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func Test_appendWriter(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	file, err := ioutil.TempFile("", "append*.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	pw := park.NewParquetWriter(sc, file, 10)
+	writeRecords(t, pw, 30)
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	for _, n := range []int{25, 7} {
+		file, err = os.OpenFile(file.Name(), os.O_RDWR, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pw, err = park.NewAppendWriter(sc, file, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeRecords(t, pw, n)
+		if err := pw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+
+	file, err = os.Open(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	meta, err := park.ReadMetaData(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.NumRows != 62 || len(meta.RowGroups) != 7 {
+		t.Fatalf("rows: %d, row groups: %d", meta.NumRows, len(meta.RowGroups))
+	}
+	var rows int64
+	pos := int64(4)
+	for _, rg := range meta.RowGroups {
+		rows += rg.NumRows
+		for _, ch := range rg.Columns {
+			if ch.MetaData.DataPageOffset != pos {
+				t.Fatalf("column chunk at %d, expected %d", ch.MetaData.DataPageOffset, pos)
+			}
+			pos += ch.MetaData.TotalCompressedSize
+		}
+	}
+	if rows != 62 {
+		t.Fatalf("row group rows: %d", rows)
+	}
+	if _, err := park.PageHeaders(meta, file); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_appendWriterSchemaMismatch(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	file, err := ioutil.TempFile("", "append*.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	pw := park.NewParquetWriter(sc, file, 10)
+	writeRecords(t, pw, 3)
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := park.NewSchema(`{"fields":[{"name":"uid","type":"string"}]}`, schema.CompressionCodec_SNAPPY)
+	if _, err := park.NewAppendWriter(other, file, 10); err == nil {
+		t.Fatal("expected schema mismatch")
+	}
+}
+
+func Test_appendWriterColumnMismatch(t *testing.T) {
+	for _, c := range []struct{ file, other string }{
+		{`"string"`, `"bytes"`},
+		{`"bytes"`, `"string"`},
+		{`{"type":"fixed","name":"v","size":4}`, `{"type":"fixed","name":"v","size":8}`},
+	} {
+		sc, _ := park.NewSchema(`{"fields":[{"name":"v","type":`+c.file+`}]}`, schema.CompressionCodec_SNAPPY)
+		other, _ := park.NewSchema(`{"fields":[{"name":"v","type":`+c.other+`}]}`, schema.CompressionCodec_SNAPPY)
+		var files [2][]byte
+		for i, s := range []*park.Schema{sc, other} {
+			file := &memFile{}
+			pw := park.NewParquetWriter(s, file, 10)
+			pw.WriteJson([]byte(`{"v":"abcd"}`))
+			if err := pw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			files[i] = file.Bytes()
+		}
+		if err := park.Merge(&bytes.Buffer{}, bytes.NewReader(files[0]), bytes.NewReader(files[1])); err == nil {
+			t.Fatalf("merged %s and %s columns", c.file, c.other)
+		}
+
+		file, err := ioutil.TempFile("", "append*.parquet")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+		if _, err := file.Write(files[0]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := park.NewAppendWriter(other, file, 10); err == nil {
+			t.Fatalf("appended %s to %s columns", c.other, c.file)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"strconv"
+	"testing"
+)
+
+type memFile struct {
+	bytes.Buffer
+}
+
+func (m *memFile) Close() error { return nil }
+
+// failFile fails every write after the first limit bytes
+type failFile struct {
+	limit int
+	n     int
+}
+
+func (f *failFile) Write(p []byte) (int, error) {
+	if f.n+len(p) > f.limit {
+		return 0, errors.New("disk full")
+	}
+	f.n += len(p)
+	return len(p), nil
+}
+func (f *failFile) Close() error { return nil }
+
+func writeRecords(t *testing.T, pw *park.ParquetWriter, n int) {
+	var format = `{"uid":"%s", "did":"%s", "type":%d, "code":%d,"time":%d}`
+	for i := 0; i < n; i++ {
+		s := fmt.Sprintf(format, "us-"+strconv.Itoa(i),
+			"c3p"+strconv.Itoa(i), i%8, (i+1)*4+100, 1588000000+i)
+		if err := pw.WriteJson([]byte(s)); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+}
+
+func Test_asyncWriteMatchesSync(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	sync := &memFile{}
+	pw := park.NewParquetWriter(sc, sync, 7)
+	writeRecords(t, pw, 100)
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, inFlight := range []int{1, 2, 8} {
+		async := &memFile{}
+		pw := park.NewParquetWriter(sc, async, 7, park.ParquetWriterAsync(inFlight))
+		writeRecords(t, pw, 100)
+		if err := pw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if pw.Rows() != 100 {
+			t.Fatalf("rows: %d", pw.Rows())
+		}
+		if !bytes.Equal(sync.Bytes(), async.Bytes()) {
+			t.Fatalf("async file (inFlight=%d) differs from sync file", inFlight)
+		}
+	}
+}
+
+func Test_asyncWriteError(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	pw := park.NewParquetWriter(sc, &failFile{limit: 200}, 5, park.ParquetWriterAsync(2))
+	var format = `{"uid":"us-%d", "code":%d}`
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		err = pw.WriteJson([]byte(fmt.Sprintf(format, i, i)))
+	}
+	if err == nil {
+		err = pw.Close()
+	}
+	if err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected disk full error, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+func Test_readBatch(t *testing.T) {
+	data := writeColumns(t, allTypesColumns, layout{}, rows(103, allTypesRecord)...)
+	pr, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderBatchSize(25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch park.Batch
+	var rows []int
+	for {
+		err := pr.ReadBatch(&batch)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batch.Columns) != 6 {
+			t.Fatalf("batch has %d columns", len(batch.Columns))
+		}
+		for _, c := range batch.Columns {
+			if c.Len() != batch.Rows || c.Nulls != nil {
+				t.Fatalf("column %s has %d rows, batch %d", c.Name, c.Len(), batch.Rows)
+			}
+		}
+		for i := 0; i < batch.Rows; i++ {
+			for k, v := range allTypesRecord(len(rows)) {
+				if got := batch.Column(k).Value(i); got != v {
+					t.Fatalf("row %d: %s is %v, expected %v", len(rows), k, got, v)
+				}
+			}
+			rows = append(rows, len(rows))
+		}
+		if d := batch.Column("d").Float64s; len(d) != batch.Rows {
+			t.Fatalf("read %d doubles", len(d))
+		}
+	}
+	if len(rows) != 103 {
+		t.Fatalf("read %d rows", len(rows))
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderColumns("i"), park.ParquetReaderFilter(park.Gt("l", int64(90)<<40)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pr.ReadBatch(&batch); err != nil {
+		t.Fatal(err)
+	}
+	i32s := batch.Column("i").Int32s
+	if batch.Rows != 12 || len(batch.Columns) != 1 || i32s[0] != 41 || i32s[11] != 52 {
+		t.Fatalf("read %d rows: %v", batch.Rows, i32s)
+	}
+	if err := pr.ReadBatch(&batch); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+var optionalSchema = `{
+  "name": "optional",
+  "type": "record",
+  "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "name", "type": ["null", "string"]},
+    {"name": "score", "type": ["double", "null"]},
+    {"name": "ok", "type": ["null", "boolean"]}
+  ]
+}`
+
+func Test_writeBatch(t *testing.T) {
+	sc, err := park.NewSchema(optionalSchema, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Field("id").Optional() || !sc.Field("name").Optional() || !sc.Field("score").Optional() {
+		t.Fatal("wrong optional fields")
+	}
+	file := &memFile{}
+	pw := park.NewParquetWriter(sc, file, 8)
+	batch := &park.Batch{Rows: 20, Columns: []park.ColumnBatch{
+		{Name: "id", Type: schema.Type_INT64},
+		{Name: "name", Type: schema.Type_BYTE_ARRAY, Nulls: make([]bool, 20)},
+		{Name: "score", Type: schema.Type_DOUBLE},
+	}}
+	for i := 0; i < 20; i++ {
+		batch.Columns[0].Int64s = append(batch.Columns[0].Int64s, int64(i))
+		batch.Columns[1].Strings = append(batch.Columns[1].Strings, fmt.Sprint("n", i))
+		batch.Columns[1].Nulls[i] = i%3 == 0
+		batch.Columns[2].Float64s = append(batch.Columns[2].Float64s, float64(i)/2)
+	}
+	if err := pw.WriteBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	record := map[string]interface{}{"id": int64(20), "ok": true}
+	if err := pw.Write(&record); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.WriteBatch(batch); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := park.NewParquetReader(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pr.Schema().Field("ok").Optional() {
+		t.Fatal("ok is not optional")
+	}
+	for i := 0; i < 41; i++ {
+		var record map[string]interface{}
+		if err := pr.Read(&record); err != nil {
+			t.Fatal(err)
+		}
+		j := i
+		if i > 20 {
+			j = i - 21
+		}
+		var name, score, ok interface{}
+		switch {
+		case i == 20:
+			ok = true
+		default:
+			score = float64(j) / 2
+			if j%3 != 0 {
+				name = fmt.Sprint("n", j)
+			}
+		}
+		if i != 20 && record["id"] != int64(j) || record["name"] != name || record["score"] != score || record["ok"] != ok {
+			t.Fatalf("row %d: %v", i, record)
+		}
+	}
+	var nulls int64
+	for _, rg := range pr.FileMetaData().RowGroups {
+		if sts := rg.Columns[1].MetaData.Statistics; sts != nil && sts.NullCount != nil {
+			nulls += *sts.NullCount
+		}
+	}
+	if nulls != 15 {
+		t.Fatalf("name has %d nulls", nulls)
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(file.Bytes()), park.ParquetReaderFilter(park.IsNull("name")), park.ParquetReaderBatchSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out park.Batch
+	if err := pr.ReadBatch(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Rows != 15 || out.Column("name").Nulls[0] != true || out.Column("id").Nulls != nil {
+		t.Fatalf("read %d rows", out.Rows)
+	}
+
+	for _, bad := range []park.Batch{
+		{Rows: 1, Columns: []park.ColumnBatch{{Name: "x", Type: schema.Type_INT64, Int64s: []int64{1}}}},
+		{Rows: 1, Columns: []park.ColumnBatch{{Name: "id", Type: schema.Type_INT32, Int32s: []int32{1}}}},
+		{Rows: 2, Columns: []park.ColumnBatch{{Name: "id", Type: schema.Type_INT64, Int64s: []int64{1}}}},
+		{Rows: 1, Columns: []park.ColumnBatch{{Name: "id", Type: schema.Type_INT64, Int64s: []int64{1}, Nulls: []bool{true}}}},
+	} {
+		if err := pw.WriteBatch(&bad); err == nil {
+			t.Fatalf("%v: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"io"
+	"testing"
+)
+
+func Test_compact(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var inputs []io.ReadSeeker
+	var format = `{"uid":"us-%d", "did":"c3p%d", "type":%d, "code":%d,"time":%d}`
+	for f := 0; f < 4; f++ {
+		file := &memFile{}
+		pw := park.NewParquetWriter(sc, file, 3)
+		for i := 0; i < 25; i++ {
+			n := f*25 + i
+			pw.WriteJson([]byte(fmt.Sprintf(format, n, n, n%8, (n*37)%100, n)))
+		}
+		if err := pw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, bytes.NewReader(file.Bytes()))
+	}
+
+	out := &memFile{}
+	err := park.Compact(out, inputs, 40,
+		park.CompactionSortBy(park.SortColumn{Name: "type"}, park.SortColumn{Name: "code", Descending: true}),
+		park.CompactionCodec(schema.CompressionCodec_GZIP))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := park.NewParquetReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := pr.FileMetaData()
+	if meta.NumRows != 100 || len(meta.RowGroups) != 3 {
+		t.Fatalf("rows: %d, row groups: %d", meta.NumRows, len(meta.RowGroups))
+	}
+	for _, rg := range meta.RowGroups {
+		if len(rg.SortingColumns) != 2 || rg.SortingColumns[0].ColumnIdx != 3 ||
+			rg.SortingColumns[1].ColumnIdx != 2 || !rg.SortingColumns[1].Descending {
+			t.Fatalf("sorting columns: %v", rg.SortingColumns)
+		}
+		if rg.Columns[0].MetaData.Codec != schema.CompressionCodec_GZIP {
+			t.Fatalf("codec: %s", rg.Columns[0].MetaData.Codec)
+		}
+	}
+
+	var prev map[string]interface{}
+	var rows int
+	for {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil {
+			pt, ct := prev["type"].(int32), record["type"].(int32)
+			if pt > ct || (pt == ct && prev["code"].(int32) < record["code"].(int32)) {
+				t.Fatalf("row %d is out of order: %v after %v", rows, record, prev)
+			}
+		}
+		prev = record
+		rows++
+	}
+	if rows != 100 {
+		t.Fatalf("read %d rows", rows)
+	}
+}
+
+func Test_compactNulls(t *testing.T) {
+	cols := []park.SchemaColumn{{Name: "id", Type: schema.Type_INT32}, {Name: "score", Type: schema.Type_INT32, Optional: true}}
+	var records []map[string]interface{}
+	for i := 0; i < 10; i++ {
+		record := map[string]interface{}{"id": int32(i)}
+		if i%3 != 0 {
+			record["score"] = int32(i % 4)
+		}
+		records = append(records, record)
+	}
+	input := writeColumns(t, cols, layout{}, records...)
+
+	for _, descending := range []bool{false, true} {
+		out := &memFile{}
+		err := park.Compact(out, []io.ReadSeeker{bytes.NewReader(input)}, 100,
+			park.CompactionSortBy(park.SortColumn{Name: "score", Descending: descending}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pr, err := park.NewParquetReader(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := pr.FileMetaData().RowGroups[0].SortingColumns
+		if len(sc) != 1 || sc[0].ColumnIdx != 1 || sc[0].Descending != descending || sc[0].NullsFirst == descending {
+			t.Fatalf("sorting columns: %v", sc)
+		}
+		var scores []interface{}
+		for {
+			var record map[string]interface{}
+			err := pr.Read(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			scores = append(scores, record["score"])
+		}
+		expected := "[<nil> <nil> <nil> <nil> 0 0 1 1 2 3]"
+		if descending {
+			expected = "[3 2 1 1 0 0 <nil> <nil> <nil> <nil>]"
+		}
+		if s := fmt.Sprint(scores); s != expected {
+			t.Fatalf("descending %v: scores %s", descending, s)
+		}
+	}
+}
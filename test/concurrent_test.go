@@ -0,0 +1,74 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"sync"
+	"testing"
+)
+
+// run with -race
+func Test_concurrentWriter(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	file := &memFile{}
+	pw := park.NewConcurrentWriter(sc, file, 10, 4)
+	const producers, n = 8, 1003
+	var wg sync.WaitGroup
+	for g := 0; g < producers; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			var format = `{"uid":"us-%d", "did":"c3p%d", "type":%d, "code":%d,"time":%d}`
+			for i := 0; i < n; i++ {
+				if err := pw.WriteJson([]byte(fmt.Sprintf(format, i, g, i%8, i, i))); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if pw.Rows() != producers*n {
+		t.Fatalf("rows: %d", pw.Rows())
+	}
+	meta, err := park.ReadMetaData(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows int64
+	for _, rg := range meta.RowGroups {
+		rows += rg.NumRows
+	}
+	if meta.NumRows != producers*n || rows != producers*n {
+		t.Fatalf("footer rows: %d, row group rows: %d", meta.NumRows, rows)
+	}
+}
+
+// run with -race, writers built from the same Schema share its pools
+func Test_writersSharingSchema(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_GZIP)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pw := park.NewParquetWriter(sc, &memFile{}, 13, park.ParquetWriterAsync(2))
+			writeRecords(t, pw, 500)
+			if err := pw.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,246 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+func writeDataset(t *testing.T) string {
+	sc, err := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ioutil.TempDir("", "dataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw, err := park.NewPartitionedWriter(sc, []string{"type", "did"}, root, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var format = `{"uid":"us-%d", "did":"%s", "type":%d, "code":%d,"time":%d}`
+	for i := 0; i < 120; i++ {
+		did := []string{"a/b", ""}[i%2]
+		if err := pw.WriteJson([]byte(fmt.Sprintf(format, i, did, i%3, i, i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"_SUCCESS", "type=0/.part-9.parquet"} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte("not parquet"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func Test_datasetReader(t *testing.T) {
+	root := writeDataset(t)
+	defer os.RemoveAll(root)
+
+	dr, err := park.NewDatasetReader(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dr.Files()) != 6 {
+		t.Fatalf("files %v", dr.Files())
+	}
+	for _, name := range []string{"type", "did"} {
+		if f := dr.Schema().Field(name); f == nil || f.Type() != map[string]schema.Type{"type": schema.Type_INT64, "did": schema.Type_BYTE_ARRAY}[name] {
+			t.Fatalf("partition column %s", name)
+		}
+	}
+	seen := map[int32]bool{}
+	for {
+		var record map[string]interface{}
+		err := dr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := record["code"].(int32)
+		did := interface{}("a/b")
+		if code%2 == 1 {
+			did = nil
+		}
+		if record["type"] != int64(code%3) || record["did"] != did || record["uid"] != fmt.Sprintf("us-%d", code) {
+			t.Fatalf("record %v", record)
+		}
+		seen[code] = true
+	}
+	if len(seen) != 120 {
+		t.Fatalf("read %d records", len(seen))
+	}
+	dr.Close()
+
+	dr, err = park.NewDatasetReader(root, park.DatasetReaderColumns("type", "code"),
+		park.DatasetReaderFilter(park.And(park.Eq("type", 1), park.Gt("code", 100), park.IsNull("did"))),
+		park.DatasetReaderBatchSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dr.Close()
+	var codes []int32
+	var batch park.Batch
+	for {
+		err := dr.ReadBatch(&batch)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batch.Columns[0].Name != "type" || batch.Columns[0].Int64s[0] != 1 {
+			t.Fatalf("batch %+v", batch.Columns)
+		}
+		codes = append(codes, batch.Columns[1].Int32s...)
+	}
+	if fmt.Sprint(codes) != "[103 109 115]" {
+		t.Fatalf("codes %v", codes)
+	}
+
+	if _, err := park.NewDatasetReader(root, park.DatasetReaderColumns("nope")); err == nil {
+		t.Fatal("read a column that is not in the dataset")
+	}
+}
+
+func readDatasetCodes(t *testing.T, root string, opts ...func(*park.DatasetReader)) []int32 {
+	dr, err := park.NewDatasetReader(root, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dr.Close()
+	var codes []int32
+	for {
+		var record map[string]interface{}
+		err := dr.Read(&record)
+		if err == io.EOF {
+			return codes
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record["type"] != int64(record["code"].(int32)%3) {
+			t.Fatalf("record %v", record)
+		}
+		codes = append(codes, record["code"].(int32))
+	}
+}
+
+func Test_datasetReaderWorkers(t *testing.T) {
+	root := writeDataset(t)
+	defer os.RemoveAll(root)
+
+	serial := readDatasetCodes(t, root)
+	if len(serial) != 120 {
+		t.Fatalf("read %d records", len(serial))
+	}
+	ordered := readDatasetCodes(t, root, park.DatasetReaderWorkers(4))
+	if fmt.Sprint(ordered) != fmt.Sprint(serial) {
+		t.Fatalf("ordered records %v, expected %v", ordered, serial)
+	}
+	budget := readDatasetCodes(t, root, park.DatasetReaderWorkers(3), park.DatasetReaderMemory(1))
+	if fmt.Sprint(budget) != fmt.Sprint(serial) {
+		t.Fatalf("records with a memory budget %v, expected %v", budget, serial)
+	}
+	unordered := readDatasetCodes(t, root, park.DatasetReaderWorkers(4), park.DatasetReaderUnordered())
+	seen := map[int32]bool{}
+	for _, code := range unordered {
+		seen[code] = true
+	}
+	if len(unordered) != 120 || len(seen) != 120 {
+		t.Fatalf("read %d unordered records, %d distinct", len(unordered), len(seen))
+	}
+	filtered := readDatasetCodes(t, root, park.DatasetReaderWorkers(2), park.DatasetReaderFilter(park.And(park.Eq("type", 2), park.Lt("code", 30))))
+	if fmt.Sprint(filtered) != "[5 11 17 23 29 2 8 14 20 26]" {
+		t.Fatalf("filtered records %v", filtered)
+	}
+
+	dr, err := park.NewDatasetReader(root, park.DatasetReaderWorkers(4), park.DatasetReaderMemory(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := dr.Read(&record); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.Read(&record); err == nil || err == io.EOF {
+		t.Fatalf("read after Close: %v", err)
+	}
+}
+
+func Test_datasetReaderClose(t *testing.T) {
+	root := writeDataset(t)
+	defer os.RemoveAll(root)
+
+	dr, err := park.NewDatasetReader(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := dr.Read(&record); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.Read(&record); err == nil || err == io.EOF {
+		t.Fatalf("read after Close: %v", err)
+	}
+	var batch park.Batch
+	if err := dr.ReadBatch(&batch); err == nil || err == io.EOF {
+		t.Fatalf("read a batch after Close: %v", err)
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_datasetReaderFile(t *testing.T) {
+	root := writeDataset(t)
+	defer os.RemoveAll(root)
+
+	files, err := filepath.Glob(filepath.Join(root, "type=1", "*", "*.parquet"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("files %v: %v", files, err)
+	}
+	dr, err := park.NewDatasetReader(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dr.Close()
+	if len(dr.Files()) != 1 || dr.Schema().Field("type") != nil || dr.Schema().Field("code") == nil {
+		t.Fatalf("files %v, columns %v", dr.Files(), dr.Columns())
+	}
+	var n int
+	for ; ; n++ {
+		var record map[string]interface{}
+		err := dr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record["code"].(int32)%3 != 1 {
+			t.Fatalf("record %v", record)
+		}
+	}
+	if n != 20 {
+		t.Fatalf("read %d records", n)
+	}
+}
@@ -0,0 +1,216 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+func Test_encodings(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		cols   []park.SchemaColumn
+		layout layout
+		n      int
+		record func(i int) map[string]interface{}
+		// the encoded chunks of these columns are at least the given
+		// times smaller than the PLAIN ones
+		smaller map[string]int64
+	}{
+		{
+			name: "delta",
+			cols: []park.SchemaColumn{
+				{Name: "time", Type: schema.Type_INT64},
+				{Name: "seq", Type: schema.Type_INT32, Optional: true},
+				{Name: "id", Type: schema.Type_BYTE_ARRAY},
+				{Name: "name", Type: schema.Type_BYTE_ARRAY},
+			},
+			layout: layout{pageSize: 300, encodings: map[string]schema.Encoding{
+				"time": schema.Encoding_DELTA_BINARY_PACKED,
+				"seq":  schema.Encoding_DELTA_BINARY_PACKED,
+				"id":   schema.Encoding_DELTA_BYTE_ARRAY,
+				"name": schema.Encoding_DELTA_LENGTH_BYTE_ARRAY,
+			}},
+			n: 1000,
+			record: func(i int) map[string]interface{} {
+				r := map[string]interface{}{
+					"time": int64(1600000000000 + i*1000 + i%7),
+					"id":   fmt.Sprintf("us-%d", 100000+i),
+					"name": fmt.Sprintf("name-%d", i%13),
+				}
+				if i%10 != 3 {
+					r["seq"] = int32(-500 + i)
+				}
+				return r
+			},
+			smaller: map[string]int64{"time": 2, "seq": 2, "id": 2},
+		},
+		{
+			name: "byte stream split",
+			cols: []park.SchemaColumn{
+				{Name: "temp", Type: schema.Type_FLOAT},
+				{Name: "pressure", Type: schema.Type_DOUBLE, Optional: true},
+			},
+			layout: layout{codec: schema.CompressionCodec_GZIP, pageSize: 2000, encodings: map[string]schema.Encoding{
+				"temp":     schema.Encoding_BYTE_STREAM_SPLIT,
+				"pressure": schema.Encoding_BYTE_STREAM_SPLIT,
+			}},
+			n: 5000,
+			record: func(i int) map[string]interface{} {
+				r := map[string]interface{}{"temp": float32(20 + float64(i%50)*0.37)}
+				if i%9 != 0 {
+					r["pressure"] = 1013.25 + float64(i%200)*0.0625
+				}
+				return r
+			},
+			smaller: map[string]int64{"temp": 1, "pressure": 1},
+		},
+		{
+			name: "rle booleans",
+			cols: boolColumns,
+			layout: layout{pageSize: 10000, encodings: map[string]schema.Encoding{
+				"is_test": schema.Encoding_RLE,
+				"maybe":   schema.Encoding_RLE,
+			}},
+			n:       10000,
+			record:  boolRecord,
+			smaller: map[string]int64{"is_test": 10},
+		},
+	} {
+		records := rows(c.n, c.record)
+		plain := writeColumns(t, c.cols, layout{codec: c.layout.codec, pageSize: c.layout.pageSize}, records...)
+		data := writeColumns(t, c.cols, c.layout, records...)
+
+		fmd, err := park.ReadMetaData(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plainFmd, err := park.ReadMetaData(bytes.NewReader(plain))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, ch := range fmd.RowGroups[0].Columns {
+			col := ch.MetaData.PathInSchema[0]
+			expected := []schema.Encoding{c.layout.encodings[col]}
+			if c.cols[i].Optional && expected[0] != schema.Encoding_RLE {
+				// the definition levels of the optional column
+				expected = append(expected, schema.Encoding_RLE)
+			}
+			if fmt.Sprint(ch.MetaData.Encodings) != fmt.Sprint(expected) {
+				t.Fatalf("%s: column %s has encodings %v", c.name, col, ch.MetaData.Encodings)
+			}
+			size := ch.MetaData.TotalCompressedSize
+			plainSize := plainFmd.RowGroups[0].Columns[i].MetaData.TotalCompressedSize
+			if f := c.smaller[col]; f > 0 && size*f > plainSize {
+				t.Fatalf("%s: column %s has %d bytes, %d bytes as PLAIN", c.name, col, size, plainSize)
+			}
+		}
+		headers, err := park.PageHeaders(fmd, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range headers {
+			if h.DataPageHeader.Encoding == schema.Encoding_PLAIN {
+				t.Fatalf("%s: page is PLAIN encoded", c.name)
+			}
+		}
+
+		pr, err := park.NewParquetReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var i int
+		for ; ; i++ {
+			var record map[string]interface{}
+			err := pr.Read(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, col := range c.cols {
+				if record[col.Name] != records[i][col.Name] {
+					t.Fatalf("%s row %d: %s is %v, expected %v", c.name, i, col.Name, record[col.Name], records[i][col.Name])
+				}
+			}
+		}
+		if i != c.n {
+			t.Fatalf("%s: read %d rows", c.name, i)
+		}
+	}
+}
+
+func Test_setEncodingErrors(t *testing.T) {
+	sc, err := park.NewSchemaFromColumns([]park.SchemaColumn{
+		{Name: "time", Type: schema.Type_INT64},
+		{Name: "id", Type: schema.Type_BYTE_ARRAY},
+	}, schema.CompressionCodec_UNCOMPRESSED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		col string
+		enc schema.Encoding
+	}{
+		{"time", schema.Encoding_DELTA_BYTE_ARRAY},
+		{"id", schema.Encoding_DELTA_BINARY_PACKED},
+		{"x", schema.Encoding_PLAIN},
+		{"time", schema.Encoding_BYTE_STREAM_SPLIT},
+	} {
+		if err := sc.SetEncoding(c.col, c.enc); err == nil {
+			t.Fatalf("%s %s: expected error", c.col, c.enc)
+		}
+	}
+}
+
+// boolColumns are required and optional BOOLEAN columns, boolRecord(i)
+// is their row i.
+var boolColumns = []park.SchemaColumn{
+	{Name: "is_test", Type: schema.Type_BOOLEAN},
+	{Name: "maybe", Type: schema.Type_BOOLEAN, Optional: true},
+}
+
+func boolRecord(i int) map[string]interface{} {
+	record := map[string]interface{}{"is_test": i == 5000}
+	if i%100 != 1 {
+		record["maybe"] = i%3 == 0
+	}
+	return record
+}
+
+// the readers generated by parquetgen use GetBools
+func Test_getBools(t *testing.T) {
+	for _, enc := range []schema.Encoding{schema.Encoding_PLAIN, schema.Encoding_RLE} {
+		l := layout{pageSize: 10000, encodings: map[string]schema.Encoding{"is_test": enc}}
+		r := bytes.NewReader(writeColumns(t, boolColumns, l, rows(10000, boolRecord)...))
+		m := park.New(
+			park.Field{Name: "is_test", Path: []string{"is_test"}, Types: []int{0}, Type: park.BoolType, RepetitionType: park.RepetitionRequired},
+			park.Field{Name: "maybe", Path: []string{"maybe"}, Types: []int{1}, Type: park.BoolType, RepetitionType: park.RepetitionOptional},
+		)
+		if err := m.ReadFooter(r); err != nil {
+			t.Fatal(err)
+		}
+		pages, err := m.Pages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pg := pages["is_test"][0]
+		if _, err := r.Seek(pg.Offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		f := park.NewRequiredField([]string{"is_test"})
+		rd, sizes, err := f.DoRead(r, pg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bools, err := park.GetBools(rd, pg.N, sizes)
+		if err != nil || len(bools) != 10000 || !bools[5000] || bools[4999] || bools[5001] {
+			t.Fatalf("%s: read %d bools: %v", enc, len(bools), err)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+// orderColumns are the columns of the orders written with an older
+// version of the schema, order(i) is their row i.
+var orderColumns = []park.SchemaColumn{
+	{Name: "id", Type: schema.Type_INT32},
+	{Name: "price", Type: schema.Type_FLOAT},
+	{Name: "qty", Type: schema.Type_INT32, Optional: true},
+	{Name: "title", Type: schema.Type_BYTE_ARRAY},
+	{Name: "legacy", Type: schema.Type_BYTE_ARRAY},
+}
+
+func order(i int) map[string]interface{} {
+	record := map[string]interface{}{
+		"id":     int32(i),
+		"price":  float32(i) + 0.5,
+		"title":  strings.Repeat("x", i%4),
+		"legacy": "gone",
+	}
+	if i%2 == 0 {
+		record["qty"] = int32(i)
+	}
+	return record
+}
+
+func Test_readerSchema(t *testing.T) {
+	data := writeColumns(t, orderColumns, layout{}, rows(45, order)...)
+	sc, err := park.NewSchema(`{"name": "order", "type": "record", "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "price", "type": "double"},
+    {"name": "qty", "type": "int", "default": 0},
+    {"name": "name", "type": "string", "aliases": ["title"]},
+    {"name": "country", "type": "string", "default": "NL"},
+    {"name": "note", "type": ["null", "string"]}
+  ]}`, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderSchema(sc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Schema() != sc {
+		t.Fatal("the schema of the reader is not the reader schema")
+	}
+	for i := 0; ; i++ {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			if i != 45 {
+				t.Fatalf("read %d records", i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		qty := int32(0)
+		if i%2 == 0 {
+			qty = int32(i)
+		}
+		if len(record) != 6 || record["id"] != int64(i) || record["price"] != float64(i)+0.5 || record["qty"] != qty ||
+			record["name"] != strings.Repeat("x", i%4) || record["country"] != "NL" || record["note"] != nil {
+			t.Fatalf("record %d is %v", i, record)
+		}
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderSchema(sc),
+		park.ParquetReaderColumns("name", "price"), park.ParquetReaderFilter(park.Gt("id", 40)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch park.Batch
+	if err := pr.ReadBatch(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch.Rows != 4 || batch.Columns[0].Name != "name" || batch.Columns[1].Float64s[0] != 41.5 {
+		t.Fatalf("batch of %d rows: %+v", batch.Rows, batch.Columns)
+	}
+}
+
+func Test_readerSchemaIncompatible(t *testing.T) {
+	data := writeColumns(t, orderColumns, layout{}, rows(5, order)...)
+	sc, err := park.NewSchemaFromColumns([]park.SchemaColumn{{Name: "price", Type: schema.Type_INT64}}, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderSchema(sc)); err == nil {
+		t.Fatal("a float column was read as long")
+	}
+}
+
+func Test_readerSchemaNoDefault(t *testing.T) {
+	data := writeColumns(t, orderColumns, layout{}, rows(5, order)...)
+	for _, field := range []string{
+		`{"name": "country", "type": "string"}`,
+		`{"name": "count", "type": "int"}`,
+	} {
+		sc, err := park.NewSchema(`{"name": "order", "type": "record", "fields": [{"name": "id", "type": "long"}, `+field+`]}`, schema.CompressionCodec_SNAPPY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderSchema(sc)); err == nil {
+			t.Fatalf("%s was read without default", field)
+		}
+	}
+	sc, err := park.NewSchemaFromColumns([]park.SchemaColumn{{Name: "id", Type: schema.Type_INT64}, {Name: "country", Type: schema.Type_BYTE_ARRAY}}, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderSchema(sc)); err == nil {
+		t.Fatal("a column without default was read")
+	}
+}
+
+func Test_checkSchemaChange(t *testing.T) {
+	before, err := park.NewSchema(`{"type": "record", "name": "order", "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "qty", "type": "int"},
+    {"name": "price", "type": "float"},
+    {"name": "name", "type": "string"},
+    {"name": "legacy", "type": "string"},
+    {"name": "note", "type": ["null", "string"]},
+    {"name": "small", "type": {"type": "int", "logicalType": "int16"}}
+  ]}`, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := park.NewSchema(`{"type": "record", "name": "order", "fields": [
+    {"name": "id", "type": "int"},
+    {"name": "qty", "type": "long"},
+    {"name": "price", "type": ["null", "float"]},
+    {"name": "name", "type": "bytes"},
+    {"name": "small", "type": {"type": "int", "logicalType": "int8"}},
+    {"name": "added", "type": "string"}
+  ]}`, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := park.CheckSchemaChange(before, after)
+	expected := []park.SchemaChange{
+		{Column: "qty", Kind: park.TypeNarrowed, Old: "required INT32", New: "required INT64"},
+		{Column: "price", Kind: park.RequiredToOptional, Old: "required FLOAT", New: "optional FLOAT"},
+		{Column: "name", Kind: park.LogicalTypeChanged, Old: "required BYTE_ARRAY UTF8", New: "required BYTE_ARRAY"},
+		{Column: "legacy", Kind: park.RequiredRemoved, Old: "required BYTE_ARRAY UTF8"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("changes %v", changes)
+	}
+	for i, c := range changes {
+		if c != expected[i] {
+			t.Fatalf("change %d is %q, expected %q", i, c, expected[i])
+		}
+	}
+	if changes := park.CheckSchemaChange(before, before); len(changes) != 0 {
+		t.Fatalf("a schema is incompatible with itself: %v", changes)
+	}
+
+	element := func(typ schema.Type, ct *schema.ConvertedType, precision int32) *schema.SchemaElement {
+		se := &schema.SchemaElement{Name: "c", Type: &typ, ConvertedType: ct,
+			RepetitionType: schema.FieldRepetitionTypePtr(schema.FieldRepetitionType_REQUIRED)}
+		if precision > 0 {
+			scale := int32(2)
+			se.Precision, se.Scale = &precision, &scale
+		}
+		return se
+	}
+	date := schema.ConvertedTypePtr(schema.ConvertedType_DATE)
+	millis := schema.ConvertedTypePtr(schema.ConvertedType_TIMESTAMP_MILLIS)
+	decimal := schema.ConvertedTypePtr(schema.ConvertedType_DECIMAL)
+	small := schema.ConvertedTypePtr(schema.ConvertedType_INT_16)
+	for i, c := range []struct {
+		before, after *schema.SchemaElement
+		changed       bool
+	}{
+		{element(schema.Type_INT32, date, 0), element(schema.Type_INT32, nil, 0), true},
+		{element(schema.Type_INT32, nil, 0), element(schema.Type_INT32, date, 0), true},
+		{element(schema.Type_INT64, millis, 0), element(schema.Type_INT64, nil, 0), true},
+		{element(schema.Type_INT32, decimal, 5), element(schema.Type_INT32, decimal, 7), true},
+		{element(schema.Type_INT64, decimal, 10), element(schema.Type_INT64, nil, 0), true},
+		{element(schema.Type_INT32, nil, 0), element(schema.Type_INT32, small, 0), false},
+		{element(schema.Type_INT64, decimal, 10), element(schema.Type_INT64, decimal, 10), false},
+	} {
+		changes := park.CheckSchemaElements([]*schema.SchemaElement{c.before}, []*schema.SchemaElement{c.after})
+		if c.changed != (len(changes) == 1 && changes[0].Kind == park.LogicalTypeChanged) || !c.changed && len(changes) != 0 {
+			t.Fatalf("case %d: changes %v", i, changes)
+		}
+	}
+
+	data := writeColumns(t, orderColumns, layout{}, rows(5, order)...)
+	footer, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes = park.CheckSchemaElements(footer.Schema, before.SchemaElements())
+	if len(changes) != 2 || changes[0].Column != "id" || changes[0].Kind != park.TypeNarrowed ||
+		changes[1].Column != "title" || changes[1].Kind != park.RequiredRemoved {
+		t.Fatalf("changes %v", changes)
+	}
+}
+
+// layout is how writeColumns writes a file: compressed with codec, in
+// pages of pageSize rows, 10 if it is 0, and with the encodings of the
+// columns in encodings.
+type layout struct {
+	codec     schema.CompressionCodec
+	pageSize  int
+	encodings map[string]schema.Encoding
+}
+
+// writeColumns writes the records to a file with the columns cols.
+func writeColumns(t *testing.T, cols []park.SchemaColumn, l layout, records ...map[string]interface{}) []byte {
+	sc, err := park.NewSchemaFromColumns(cols, l.codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for col, enc := range l.encodings {
+		if err := sc.SetEncoding(col, enc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if l.pageSize == 0 {
+		l.pageSize = 10
+	}
+	file := &memFile{}
+	pw := park.NewParquetWriter(sc, file, l.pageSize)
+	for _, record := range records {
+		if err := pw.Write(&record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes()
+}
+
+// rows returns the records of n rows, record(i) is row i.
+func rows(n int, record func(i int) map[string]interface{}) []map[string]interface{} {
+	records := make([]map[string]interface{}, n)
+	for i := range records {
+		records[i] = record(i)
+	}
+	return records
+}
+
+func Test_mergeSchemas(t *testing.T) {
+	files := [][]byte{
+		writeColumns(t, []park.SchemaColumn{
+			{Name: "id", Type: schema.Type_INT32},
+			{Name: "name", Type: schema.Type_BYTE_ARRAY},
+			{Name: "small", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 16}},
+		}, layout{}, map[string]interface{}{"id": int32(1), "name": "a", "small": uint16(60000)}),
+		writeColumns(t, []park.SchemaColumn{
+			{Name: "id", Type: schema.Type_INT64},
+			{Name: "name", Type: schema.Type_BYTE_ARRAY, Optional: true},
+			{Name: "ratio", Type: schema.Type_FLOAT},
+		}, layout{}, map[string]interface{}{"id": int64(2), "ratio": float32(0.5)}),
+		writeColumns(t, []park.SchemaColumn{
+			{Name: "id", Type: schema.Type_INT32},
+			{Name: "name", Type: schema.Type_BYTE_ARRAY},
+			{Name: "small", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 16, IsSigned: true}},
+			{Name: "ratio", Type: schema.Type_INT32},
+		}, layout{}, map[string]interface{}{"id": int32(3), "name": "c", "small": int16(-5), "ratio": int32(7)}),
+	}
+	inputs := make([]io.ReadSeeker, len(files))
+	for i, data := range files {
+		inputs[i] = bytes.NewReader(data)
+	}
+	merged, err := park.MergeSchemas(inputs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name     string
+		t        schema.Type
+		optional bool
+	}{
+		{"id", schema.Type_INT64, false},
+		{"name", schema.Type_BYTE_ARRAY, true},
+		{"small", schema.Type_INT32, true},
+		{"ratio", schema.Type_FLOAT, true},
+	}
+	if len(merged.Schema.Fields) != len(expected) {
+		t.Fatalf("%d fields", len(merged.Schema.Fields))
+	}
+	for i, e := range expected {
+		f := &merged.Schema.Fields[i]
+		if f.Name() != e.name || f.Type() != e.t || f.Optional() != e.optional || f.IntType() != nil || f.Binary() {
+			t.Fatalf("field %d is %s %s optional %v", i, f.Name(), f.Type(), f.Optional())
+		}
+	}
+	missing := fmt.Sprint(merged.Missing)
+	if missing != "[[ratio] [small] []]" {
+		t.Fatalf("missing columns %s", missing)
+	}
+
+	var values []string
+	for _, data := range files {
+		pr, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderSchema(merged.Schema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var record map[string]interface{}
+		if err := pr.Read(&record); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, fmt.Sprintf("%v %v %v %v", record["id"], record["name"], record["small"], record["ratio"]))
+	}
+	if s := strings.Join(values, ","); s != "1 a 60000 <nil>,2 <nil> <nil> 0.5,3 c -5 7" {
+		t.Fatalf("records %s", s)
+	}
+
+	bad := writeColumns(t, []park.SchemaColumn{{Name: "id", Type: schema.Type_BYTE_ARRAY}}, layout{}, map[string]interface{}{"id": "x"})
+	if _, err := park.MergeSchemas(bytes.NewReader(files[0]), bytes.NewReader(bad)); err == nil {
+		t.Fatal("merged an int and a string column")
+	}
+}
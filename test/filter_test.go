@@ -0,0 +1,380 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+// readFiltered returns the "i" column of the matching records and the
+// chunks of column that were read.
+func readFiltered(t *testing.T, data []byte, column string, pred park.Predicate, opts ...func(*park.ParquetReader)) ([]int32, int) {
+	r := &trackingReader{Reader: bytes.NewReader(data)}
+	pr, err := park.NewParquetReader(r, append(opts, park.ParquetReaderFilter(pred))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []int32
+	for {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i, ok := record["i"].(int32); ok {
+			out = append(out, i)
+		}
+	}
+	var chunks int
+	for _, rg := range pr.FileMetaData().RowGroups {
+		for _, ch := range rg.Columns {
+			if ch.MetaData.PathInSchema[0] != column {
+				continue
+			}
+			start := ch.MetaData.DataPageOffset
+			end := start + ch.MetaData.TotalCompressedSize
+			for _, rd := range r.reads {
+				if rd[0] < end && rd[1] > start {
+					chunks++
+					break
+				}
+			}
+		}
+	}
+	return out, chunks
+}
+
+func Test_filterStatistics(t *testing.T) {
+	data := writeColumns(t, allTypesColumns, layout{}, rows(103, allTypesRecord)...)
+
+	tests := []struct {
+		name   string
+		pred   park.Predicate
+		rows   []int32
+		chunks int
+	}{
+		{"eq", park.Eq("i", 5), []int32{5}, 1},
+		{"lt", park.Lt("l", int64(3)<<40), []int32{-50, -49, -48}, 1},
+		{"gt", park.Gt("d", 100.0/3), []int32{51, 52}, 1},
+		{"in", park.In("i", -50, 52, 99), []int32{-50, 52}, 2},
+		{"and", park.And(park.Gt("i", 20), park.Lt("f", 18)), []int32{21}, 1},
+		{"or", park.Or(park.Eq("s", "s-7"), park.Gt("i", 51)), []int32{-43, 52}, 2},
+		{"not", park.And(park.Lt("i", -45), park.Not(park.Eq("b", true))), []int32{-49, -48, -46}, 1},
+		{"null", park.IsNull("i"), nil, 0},
+		{"none", park.Gt("i", 1000), nil, 0},
+	}
+	for _, tt := range tests {
+		rows, chunks := readFiltered(t, data, "i", tt.pred)
+		if len(rows) != len(tt.rows) {
+			t.Fatalf("%s: read %v, expected %v", tt.name, rows, tt.rows)
+		}
+		for j := range rows {
+			if rows[j] != tt.rows[j] {
+				t.Fatalf("%s: read %v, expected %v", tt.name, rows, tt.rows)
+			}
+		}
+		if chunks != tt.chunks {
+			t.Fatalf("%s: read %d chunks, expected %d", tt.name, chunks, tt.chunks)
+		}
+	}
+
+	// the filter columns do not have to be selected
+	rows, _ := readFiltered(t, data, "i", park.Eq("s", "s-60"), park.ParquetReaderColumns("i"))
+	if len(rows) != 1 || rows[0] != 10 {
+		t.Fatalf("read %v", rows)
+	}
+
+	for _, pred := range []park.Predicate{park.Eq("x", 1), park.Eq("i", "a"), park.Eq("i", int64(1)<<40)} {
+		if _, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderFilter(pred)); err == nil {
+			t.Fatalf("%v: expected error", pred)
+		}
+	}
+}
+
+// Test_filterIndexes removes the chunk statistics and adds a column
+// index to "i" and a bloom filter to "s".
+func Test_filterIndexes(t *testing.T) {
+	data := writeColumns(t, allTypesColumns, layout{}, rows(103, allTypesRecord)...)
+	fmd, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+
+	out := &bytes.Buffer{}
+	out.Write(data[:len(data)-8-int(footerSize(data))])
+	for g, rg := range fmd.RowGroups {
+		for _, ch := range rg.Columns {
+			sts := ch.MetaData.Statistics
+			ch.MetaData.Statistics = nil
+			switch ch.MetaData.PathInSchema[0] {
+			case "i":
+				idx := &schema.ColumnIndex{
+					NullPages:     []bool{false},
+					MinValues:     [][]byte{sts.MinValue},
+					MaxValues:     [][]byte{sts.MaxValue},
+					BoundaryOrder: schema.BoundaryOrder_UNORDERED,
+				}
+				buf, err := ts.Write(context.TODO(), idx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				offset, length := int64(out.Len()), int32(len(buf))
+				ch.ColumnIndexOffset, ch.ColumnIndexLength = &offset, &length
+				out.Write(buf)
+			case "s":
+				b := park.NewBloomFilter(64)
+				for i := g * 10; i < g*10+int(rg.NumRows); i++ {
+					h, err := park.BloomFilterHash(allTypesRecord(i)["s"])
+					if err != nil {
+						t.Fatal(err)
+					}
+					b.Insert(h)
+				}
+				offset := int64(out.Len())
+				ch.MetaData.BloomFilterOffset = &offset
+				if err := park.WriteBloomFilter(out, b); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	if err := park.WriteFooter(out, fmd); err != nil {
+		t.Fatal(err)
+	}
+	out.WriteString("PAR1")
+	data = out.Bytes()
+
+	rows, chunks := readFiltered(t, data, "i", park.Gt("i", 45))
+	if len(rows) != 7 || chunks != 2 {
+		t.Fatalf("read %v from %d chunks", rows, chunks)
+	}
+
+	// "s-55" is between the min "s-0" and the max "s-9" of the first row
+	// group, only the bloom filter tells that it is not there.
+	rows, chunks = readFiltered(t, data, "s", park.Eq("s", "s-55"))
+	if len(rows) != 1 || rows[0] != 5 || chunks != 1 {
+		t.Fatalf("read %v from %d chunks", rows, chunks)
+	}
+	rows, chunks = readFiltered(t, data, "s", park.Eq("s", "s-555"))
+	if len(rows) != 0 || chunks != 0 {
+		t.Fatalf("read %v from %d chunks", rows, chunks)
+	}
+}
+
+func footerSize(data []byte) uint32 {
+	b := data[len(data)-8:]
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+// mergePages rewrites a file as one row group whose column chunks have a
+// page for every row group of the file, with column and offset indexes.
+func mergePages(t *testing.T, data []byte) []byte {
+	fmd, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := thrift.NewTSerializer()
+	ts.Protocol = thrift.NewTCompactProtocolFactory().GetProtocol(ts.Transport)
+
+	out := &bytes.Buffer{}
+	out.WriteString("PAR1")
+	merged := &schema.RowGroup{NumRows: fmd.NumRows}
+	var columnIndexes []*schema.ColumnIndex
+	var offsetIndexes []*schema.OffsetIndex
+	for j := range fmd.RowGroups[0].Columns {
+		md := *fmd.RowGroups[0].Columns[j].MetaData
+		md.DataPageOffset = int64(out.Len())
+		md.NumValues, md.TotalCompressedSize, md.TotalUncompressedSize, md.Statistics = 0, 0, 0, nil
+		ci := &schema.ColumnIndex{BoundaryOrder: schema.BoundaryOrder_UNORDERED}
+		oi := &schema.OffsetIndex{}
+		var first int64
+		for _, rg := range fmd.RowGroups {
+			src := rg.Columns[j].MetaData
+			if src.DictionaryPageOffset != nil {
+				t.Fatalf("column %v has a dictionary page", src.PathInSchema)
+			}
+			oi.PageLocations = append(oi.PageLocations, &schema.PageLocation{
+				Offset:             int64(out.Len()),
+				CompressedPageSize: int32(src.TotalCompressedSize),
+				FirstRowIndex:      first,
+			})
+			out.Write(data[src.DataPageOffset : src.DataPageOffset+src.TotalCompressedSize])
+			sts := src.Statistics
+			if sts == nil {
+				ci = nil
+			}
+			if ci != nil {
+				ci.NullPages = append(ci.NullPages, sts.MinValue == nil)
+				ci.MinValues = append(ci.MinValues, append([]byte{}, sts.MinValue...))
+				ci.MaxValues = append(ci.MaxValues, append([]byte{}, sts.MaxValue...))
+				ci.NullCounts = append(ci.NullCounts, sts.GetNullCount())
+			}
+			first += rg.NumRows
+			md.NumValues += src.NumValues
+			md.TotalCompressedSize += src.TotalCompressedSize
+			md.TotalUncompressedSize += src.TotalUncompressedSize
+		}
+		merged.Columns = append(merged.Columns, &schema.ColumnChunk{FileOffset: md.DataPageOffset, MetaData: &md})
+		merged.TotalByteSize += md.TotalUncompressedSize
+		columnIndexes = append(columnIndexes, ci)
+		offsetIndexes = append(offsetIndexes, oi)
+	}
+	for j, ch := range merged.Columns {
+		if ci := columnIndexes[j]; ci != nil {
+			buf, err := ts.Write(context.TODO(), ci)
+			if err != nil {
+				t.Fatal(err)
+			}
+			offset, length := int64(out.Len()), int32(len(buf))
+			ch.ColumnIndexOffset, ch.ColumnIndexLength = &offset, &length
+			out.Write(buf)
+		}
+		buf, err := ts.Write(context.TODO(), offsetIndexes[j])
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset, length := int64(out.Len()), int32(len(buf))
+		ch.OffsetIndexOffset, ch.OffsetIndexLength = &offset, &length
+		out.Write(buf)
+	}
+	fmd.RowGroups = []*schema.RowGroup{merged}
+	if err := park.WriteFooter(out, fmd); err != nil {
+		t.Fatal(err)
+	}
+	out.WriteString("PAR1")
+	return out.Bytes()
+}
+
+func Test_filterPages(t *testing.T) {
+	data := mergePages(t, writeColumns(t, allTypesColumns, layout{}, rows(103, allTypesRecord)...))
+	fmd, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fmd.RowGroups) != 1 {
+		t.Fatalf("%d row groups", len(fmd.RowGroups))
+	}
+
+	tests := []struct {
+		name  string
+		pred  park.Predicate
+		rows  []int32
+		pages []int // pages of every column that are read
+	}{
+		{"gt", park.Gt("i", 45), []int32{46, 47, 48, 49, 50, 51, 52}, []int{9, 10}},
+		{"or", park.Or(park.Lt("i", -47), park.Eq("i", 52)), []int32{-50, -49, -48, 52}, []int{0, 10}},
+		{"and", park.And(park.Gt("i", -45), park.Lt("i", -35)), []int32{-44, -43, -42, -41, -40, -39, -38, -37, -36}, []int{0, 1}},
+		{"none", park.And(park.Gt("i", 45), park.Lt("i", -45)), nil, nil},
+		{"not", park.Not(park.Gt("i", -49)), []int32{-50, -49}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		r := &trackingReader{Reader: bytes.NewReader(data)}
+		pr, err := park.NewParquetReader(r, park.ParquetReaderFilter(tt.pred))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rows []int32
+		for {
+			var record map[string]interface{}
+			err := pr.Read(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %s", tt.name, err)
+			}
+			i := record["i"].(int32)
+			expected := allTypesRecord(int(i) + 50)
+			if record["s"] != expected["s"] || record["d"] != expected["d"] || record["b"] != expected["b"] {
+				t.Fatalf("%s: record %v", tt.name, record)
+			}
+			rows = append(rows, i)
+		}
+		if fmt.Sprint(rows) != fmt.Sprint(tt.rows) {
+			t.Fatalf("%s: read %v, expected %v", tt.name, rows, tt.rows)
+		}
+		for _, ch := range fmd.RowGroups[0].Columns {
+			oi := offsetIndex(t, data, ch)
+			var read []int
+			for p, loc := range oi.PageLocations {
+				for _, rd := range r.reads {
+					if rd[0] < loc.Offset+int64(loc.CompressedPageSize) && rd[1] > loc.Offset {
+						read = append(read, p)
+						break
+					}
+				}
+			}
+			if fmt.Sprint(read) != fmt.Sprint(tt.pages) {
+				t.Fatalf("%s: read the pages %v of %s, expected %v", tt.name, read, ch.MetaData.PathInSchema[0], tt.pages)
+			}
+		}
+	}
+}
+
+func Test_filterPagesOptional(t *testing.T) {
+	data := mergePages(t, writeColumns(t, orderColumns, layout{}, rows(45, order)...))
+	pr, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderFilter(park.Gt("id", 36)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch park.Batch
+	if err := pr.ReadBatch(&batch); err != nil {
+		t.Fatal(err)
+	}
+	ids, qty := batch.Column("id"), batch.Column("qty")
+	if batch.Rows != 8 {
+		t.Fatalf("read %d rows", batch.Rows)
+	}
+	for i := 0; i < batch.Rows; i++ {
+		id := ids.Int32s[i]
+		if id != int32(37+i) {
+			t.Fatalf("row %d has id %d", i, id)
+		}
+		if q := qty.Value(i); id%2 == 0 && q != id || id%2 == 1 && q != nil {
+			t.Fatalf("row %d has qty %v", id, q)
+		}
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderFilter(park.IsNull("qty")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record["qty"] != nil || record["id"].(int32)%2 == 0 {
+			t.Fatalf("record %v", record)
+		}
+		n++
+	}
+	if n != 22 {
+		t.Fatalf("read %d records", n)
+	}
+}
+
+func offsetIndex(t *testing.T, data []byte, ch *schema.ColumnChunk) *schema.OffsetIndex {
+	oi := schema.NewOffsetIndex()
+	r := bytes.NewReader(data[*ch.OffsetIndexOffset:])
+	if err := oi.Read(thrift.NewTCompactProtocol(&thrift.StreamTransport{Reader: r})); err != nil {
+		t.Fatal(err)
+	}
+	return oi
+}
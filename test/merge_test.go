@@ -0,0 +1,75 @@
+package test
+
+import (
+	"bytes"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"io"
+	"testing"
+)
+
+func Test_merge(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_GZIP)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var inputs []io.ReadSeeker
+	var sources [][]byte
+	for _, n := range []int{15, 1, 30} {
+		file := &memFile{}
+		pw := park.NewParquetWriter(sc, file, 10)
+		writeRecords(t, pw, n)
+		if err := pw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, file.Bytes())
+		inputs = append(inputs, bytes.NewReader(file.Bytes()))
+	}
+
+	out := &bytes.Buffer{}
+	if err := park.Merge(out, inputs...); err != nil {
+		t.Fatal(err)
+	}
+	merged := bytes.NewReader(out.Bytes())
+	meta, err := park.ReadMetaData(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.NumRows != 46 || len(meta.RowGroups) != 6 {
+		t.Fatalf("rows: %d, row groups: %d", meta.NumRows, len(meta.RowGroups))
+	}
+	if _, err := park.PageHeaders(meta, merged); err != nil {
+		t.Fatal(err)
+	}
+
+	// the column chunks are the bytes of the source files
+	var chunks [][]byte
+	for _, src := range sources {
+		m, _ := park.ReadMetaData(bytes.NewReader(src))
+		for _, rg := range m.RowGroups {
+			for _, ch := range rg.Columns {
+				o := ch.MetaData.DataPageOffset
+				chunks = append(chunks, src[o:o+ch.MetaData.TotalCompressedSize])
+			}
+		}
+	}
+	var i int
+	for _, rg := range meta.RowGroups {
+		for _, ch := range rg.Columns {
+			o := ch.MetaData.DataPageOffset
+			if !bytes.Equal(out.Bytes()[o:o+ch.MetaData.TotalCompressedSize], chunks[i]) {
+				t.Fatalf("column chunk %d was not copied as is", i)
+			}
+			i++
+		}
+	}
+
+	other, _ := park.NewSchema(`{"fields":[{"name":"uid","type":"string"}]}`, schema.CompressionCodec_GZIP)
+	file := &memFile{}
+	pw := park.NewParquetWriter(other, file, 10)
+	pw.WriteJson([]byte(`{"uid":"x"}`))
+	pw.Close()
+	if err := park.Merge(&bytes.Buffer{}, bytes.NewReader(sources[0]), bytes.NewReader(file.Bytes())); err == nil {
+		t.Fatal("expected schema mismatch")
+	}
+}
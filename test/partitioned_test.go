@@ -0,0 +1,76 @@
+package test
+
+import (
+	"fmt"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_partitionedWriter(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	root, err := ioutil.TempDir("", "partitioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	pw, err := park.NewPartitionedWriter(sc, []string{"type", "did"}, root, 10, park.PartitionedWriterMaxOpen(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var format = `{"uid":"us-%d", "did":"%s", "type":%d, "code":%d,"time":%d}`
+	for i := 0; i < 120; i++ {
+		did := []string{"a/b", ""}[i%2]
+		if err := pw.WriteJson([]byte(fmt.Sprintf(format, i, did, i%3, i, i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(root, "*", "*", "*.parquet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows int64
+	for _, name := range files {
+		rel, _ := filepath.Rel(root, name)
+		dir := filepath.Dir(rel)
+		switch dir {
+		case "type=0/did=a%2Fb", "type=1/did=__HIVE_DEFAULT_PARTITION__", "type=2/did=a%2Fb",
+			"type=0/did=__HIVE_DEFAULT_PARTITION__", "type=1/did=a%2Fb", "type=2/did=__HIVE_DEFAULT_PARTITION__":
+		default:
+			t.Fatalf("unexpected partition %s", dir)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, err := park.ReadMetaData(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, se := range meta.Schema {
+			if se.Name == "type" || se.Name == "did" {
+				t.Fatalf("%s: partition column %s stored in file", rel, se.Name)
+			}
+		}
+		rows += meta.NumRows
+	}
+	if rows != 120 || pw.Rows() != 120 {
+		t.Fatalf("rows: %d", rows)
+	}
+	// every record switches partition and only 2 files may be open
+	if len(files) <= 6 {
+		t.Fatalf("expected files to be closed and reopened as part-N, got %d files", len(files))
+	}
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"io"
+	"testing"
+)
+
+// allTypesColumns are required columns of the usual types,
+// allTypesRecord(i) is their row i.
+var allTypesColumns = []park.SchemaColumn{
+	{Name: "s", Type: schema.Type_BYTE_ARRAY},
+	{Name: "i", Type: schema.Type_INT32},
+	{Name: "l", Type: schema.Type_INT64},
+	{Name: "f", Type: schema.Type_FLOAT},
+	{Name: "d", Type: schema.Type_DOUBLE},
+	{Name: "b", Type: schema.Type_BOOLEAN},
+}
+
+func allTypesRecord(i int) map[string]interface{} {
+	return map[string]interface{}{
+		"s": fmt.Sprintf("s-%d", i),
+		"i": int32(i - 50),
+		"l": int64(i) << 40,
+		"f": float32(i) / 4,
+		"d": float64(i) / 3,
+		"b": i%3 == 0,
+	}
+}
+
+func Test_readAllTypes(t *testing.T) {
+	for _, codec := range []schema.CompressionCodec{schema.CompressionCodec_SNAPPY, schema.CompressionCodec_GZIP, schema.CompressionCodec_UNCOMPRESSED} {
+		data := writeColumns(t, allTypesColumns, layout{codec: codec}, rows(103, allTypesRecord)...)
+		pr, err := park.NewParquetReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var record map[string]interface{}
+		var i int
+		for ; ; i++ {
+			err := pr.Read(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range allTypesRecord(i) {
+				if record[k] != v {
+					t.Fatalf("%s row %d: %s is %v, expected %v", codec, i, k, record[k], v)
+				}
+			}
+		}
+		if i != 103 || pr.Rows() != 103 {
+			t.Fatalf("%s: read %d rows", codec, i)
+		}
+	}
+}
+
+// trackingReader records the offsets of every Read
+type trackingReader struct {
+	*bytes.Reader
+	reads [][2]int64
+}
+
+func (r *trackingReader) Read(p []byte) (int, error) {
+	pos, _ := r.Seek(0, io.SeekCurrent)
+	n, err := r.Reader.Read(p)
+	r.reads = append(r.reads, [2]int64{pos, pos + int64(n)})
+	return n, err
+}
+
+func Test_readProjection(t *testing.T) {
+	data := writeColumns(t, allTypesColumns, layout{}, rows(55, allTypesRecord)...)
+	r := &trackingReader{Reader: bytes.NewReader(data)}
+	pr, err := park.NewParquetReader(r, park.ParquetReaderColumns("d", "s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var i int
+	for ; ; i++ {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := allTypesRecord(i)
+		if len(record) != 2 || record["d"] != expected["d"] || record["s"] != expected["s"] {
+			t.Fatalf("row %d: %v", i, record)
+		}
+	}
+	if i != 55 {
+		t.Fatalf("read %d rows", i)
+	}
+	for _, rg := range pr.FileMetaData().RowGroups {
+		for _, ch := range rg.Columns {
+			name := ch.MetaData.PathInSchema[0]
+			if name == "d" || name == "s" {
+				continue
+			}
+			start := ch.MetaData.DataPageOffset
+			end := start + ch.MetaData.TotalCompressedSize
+			for _, rd := range r.reads {
+				if rd[0] < end && rd[1] > start {
+					t.Fatalf("column %s was read", name)
+				}
+			}
+		}
+	}
+
+	if _, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderColumns("x")); err == nil {
+		t.Fatal("expected unknown column error")
+	}
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+	"io"
+	"testing"
+)
+
+func Test_rollingWriter(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	files := map[string]*memFile{}
+	var finished []park.RollingFile
+	rw := park.NewRollingWriter(sc, func(i int) (string, io.WriteCloser, error) {
+		path := fmt.Sprintf("part-%d.parquet", i)
+		files[path] = &memFile{}
+		return path, files[path], nil
+	}, 10,
+		park.RollingWriterMaxRows(40),
+		park.RollingWriterStats("code", "uid", "time"),
+		park.RollingWriterOnFinish(func(f park.RollingFile) error {
+			finished = append(finished, f)
+			return nil
+		}))
+	var format = `{"uid":"us-%03d", "did":"c3p%d", "type":%d, "code":%d,"time":%d}`
+	for i := 0; i < 100; i++ {
+		if err := rw.WriteJson([]byte(fmt.Sprintf(format, i, i, i%8, -i, 1588000000+i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(finished) != 3 || len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(finished))
+	}
+	for i, f := range finished {
+		rows := []int64{40, 40, 20}[i]
+		if f.Path != fmt.Sprintf("part-%d.parquet", i) || f.Rows != rows {
+			t.Fatalf("file %d: %+v", i, f)
+		}
+		if f.Bytes != int64(files[f.Path].Len()) {
+			t.Fatalf("file %d: %d bytes, reported %d", i, files[f.Path].Len(), f.Bytes)
+		}
+		meta, err := park.ReadMetaData(bytes.NewReader(files[f.Path].Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.NumRows != rows {
+			t.Fatalf("file %d: footer rows %d", i, meta.NumRows)
+		}
+		first := i * 40
+		last := first + int(rows) - 1
+		if f.Min["code"] != int32(-last) || f.Max["code"] != int32(-first) {
+			t.Fatalf("file %d: code min/max %v %v", i, f.Min["code"], f.Max["code"])
+		}
+		if f.Min["uid"] != fmt.Sprintf("us-%03d", first) || f.Max["uid"] != fmt.Sprintf("us-%03d", last) {
+			t.Fatalf("file %d: uid min/max %v %v", i, f.Min["uid"], f.Max["uid"])
+		}
+		if f.Max["time"] != int64(1588000000+last) {
+			t.Fatalf("file %d: time max %v", i, f.Max["time"])
+		}
+	}
+}
+
+// Test_rollingWriterAsync rotates by size while the row groups are written
+// by the goroutine of ParquetWriterAsync, run it with -race.
+func Test_rollingWriterAsync(t *testing.T) {
+	sc, e := park.NewSchema(avroSchema, schema.CompressionCodec_SNAPPY)
+	if e != nil {
+		t.Fatal(e)
+	}
+	files := map[string]*memFile{}
+	var finished []park.RollingFile
+	rw := park.NewRollingWriter(sc, func(i int) (string, io.WriteCloser, error) {
+		path := fmt.Sprintf("part-%d.parquet", i)
+		files[path] = &memFile{}
+		return path, files[path], nil
+	}, 10,
+		park.RollingWriterMaxBytes(2000),
+		park.RollingWriterParquetOptions(park.ParquetWriterAsync(2)),
+		park.RollingWriterOnFinish(func(f park.RollingFile) error {
+			finished = append(finished, f)
+			return nil
+		}))
+	var format = `{"uid":"us-%03d", "did":"c3p%d", "type":%d, "code":%d,"time":%d}`
+	for i := 0; i < 500; i++ {
+		if err := rw.WriteJson([]byte(fmt.Sprintf(format, i, i, i%8, -i, 1588000000+i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(finished) < 2 {
+		t.Fatalf("expected the files to rotate, got %d", len(finished))
+	}
+	var rows int64
+	for i, f := range finished {
+		if f.Bytes != int64(files[f.Path].Len()) {
+			t.Fatalf("file %d: %d bytes, reported %d", i, files[f.Path].Len(), f.Bytes)
+		}
+		meta, err := park.ReadMetaData(bytes.NewReader(files[f.Path].Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.NumRows != f.Rows {
+			t.Fatalf("file %d: footer rows %d, reported %d", i, meta.NumRows, f.Rows)
+		}
+		rows += f.Rows
+	}
+	if rows != 500 {
+		t.Fatalf("wrote %d rows", rows)
+	}
+}
@@ -0,0 +1,549 @@
+package test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	park "github.com/houkx/parquet-go/parquet"
+	"github.com/houkx/parquet-go/parquet/schema"
+)
+
+// fixedColumns are FIXED_LEN_BYTE_ARRAY and INT96 columns, fixedRecord(i)
+// is their row i.
+var fixedColumns = []park.SchemaColumn{
+	{Name: "id", Type: schema.Type_INT32},
+	{Name: "hash", Type: schema.Type_FIXED_LEN_BYTE_ARRAY, Length: 16},
+	{Name: "tag", Type: schema.Type_FIXED_LEN_BYTE_ARRAY, Length: 4, Optional: true},
+	{Name: "ts", Type: schema.Type_INT96},
+}
+
+func fixedRecord(i int) map[string]interface{} {
+	record := map[string]interface{}{
+		"id":   int32(i),
+		"hash": md5.Sum([]byte(fmt.Sprint(i))),
+		"ts":   time.Date(1960+i, 3, 4, 5, 6, 7, 8*i, time.UTC),
+	}
+	switch i % 3 {
+	case 1:
+		record["tag"] = []byte{byte(i), 1, 2, 3}
+	case 2:
+		record["tag"] = base64.StdEncoding.EncodeToString([]byte{byte(i), 1, 2, 3})
+	}
+	return record
+}
+
+// checkAvroColumns fails unless the avro schema has the columns cols.
+func checkAvroColumns(t *testing.T, avro string, cols []park.SchemaColumn) {
+	a, err := park.NewSchema(avro, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := park.NewSchemaFromColumns(cols, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := append(park.CheckSchemaChange(a, c), park.CheckSchemaChange(c, a)...); len(changes) != 0 {
+		t.Fatalf("the avro schema differs from the columns: %v", changes)
+	}
+}
+
+func Test_fixedAndInt96(t *testing.T) {
+	checkAvroColumns(t, `{"fields": [
+    {"name": "id", "type": "int"},
+    {"name": "hash", "type": {"type": "fixed", "name": "md5", "size": 16}},
+    {"name": "tag", "type": ["null", {"type": "fixed", "name": "tag", "size": 4}]},
+    {"name": "ts", "type": "int96"}
+  ]}`, fixedColumns)
+	data := writeColumns(t, fixedColumns, layout{}, rows(45, fixedRecord)...)
+	pr, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderBinary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, se := range pr.FileMetaData().Schema[1:] {
+		var length int32
+		switch se.Name {
+		case "hash":
+			length = 16
+		case "tag":
+			length = 4
+		}
+		if se.GetTypeLength() != length || (length == 0 && se.TypeLength != nil) {
+			t.Fatalf("%s has type length %v", se.Name, se.TypeLength)
+		}
+	}
+	for i := 0; ; i++ {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			if i != 45 {
+				t.Fatalf("read %d rows", i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := fixedRecord(i)
+		hash := expected["hash"].([16]byte)
+		if !bytes.Equal(record["hash"].([]byte), hash[:]) {
+			t.Fatalf("row %d: hash is %x", i, record["hash"])
+		}
+		if i%3 == 0 && record["tag"] != nil {
+			t.Fatalf("row %d: tag is %v", i, record["tag"])
+		}
+		if i%3 != 0 && !bytes.Equal(record["tag"].([]byte), []byte{byte(i), 1, 2, 3}) {
+			t.Fatalf("row %d: tag is %v", i, record["tag"])
+		}
+		if ts := record["ts"].(time.Time); !ts.Equal(expected["ts"].(time.Time)) {
+			t.Fatalf("row %d: ts is %s", i, ts)
+		}
+	}
+
+	hash := md5.Sum([]byte("17"))
+	pr, err = park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderFilter(park.Eq("hash", hash[:])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := pr.Read(&record); err != nil || record["id"] != int32(17) {
+		t.Fatalf("read %v, %v", record, err)
+	}
+	if err := pr.Read(&record); err != io.EOF {
+		t.Fatalf("read %v, %v", record, err)
+	}
+
+	// the schema of the file keeps the lengths
+	sc, err := park.NewSchemaFromFileMetaData(pr.FileMetaData(), schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := sc.Field("tag"); f.Type() != schema.Type_FIXED_LEN_BYTE_ARRAY || !f.Optional() {
+		t.Fatalf("tag is %s", f.Type())
+	}
+	if f := sc.Field("ts"); f.Type() != schema.Type_INT96 {
+		t.Fatalf("ts is %s", f.Type())
+	}
+}
+
+func Test_int96Time(t *testing.T) {
+	for _, ts := range []time.Time{
+		time.Unix(0, 0),
+		time.Date(1900, 1, 1, 23, 59, 59, 999999999, time.UTC),
+		time.Date(2021, 11, 12, 16, 11, 51, 123, time.FixedZone("x", 3600)),
+	} {
+		b := park.TimeToInt96(ts)
+		if got := park.Int96ToTime(b); !got.Equal(ts) || got.Location() != time.UTC {
+			t.Fatalf("%s became %s", ts, got)
+		}
+	}
+	// 2000-01-01 00:00:01 is julian day 2451545
+	b := park.TimeToInt96(time.Date(2000, 1, 1, 0, 0, 1, 0, time.UTC))
+	expected := []byte{0x00, 0xca, 0x9a, 0x3b, 0, 0, 0, 0, 0x59, 0x68, 0x25, 0x00}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("encoded %x", b)
+	}
+}
+
+func Test_binary(t *testing.T) {
+	cols := []park.SchemaColumn{
+		{Name: "name", Type: schema.Type_BYTE_ARRAY},
+		{Name: "blob", Type: schema.Type_BYTE_ARRAY, Binary: true},
+		{Name: "maybe", Type: schema.Type_BYTE_ARRAY, Binary: true, Optional: true},
+	}
+	checkAvroColumns(t, `{"fields": [
+    {"name": "name", "type": "string"},
+    {"name": "blob", "type": "bytes"},
+    {"name": "maybe", "type": ["null", "bytes"]}
+  ]}`, cols)
+	blob := func(i int) []byte { return []byte{0xff, byte(i), 0, 0xc3} }
+	data := writeColumns(t, cols, layout{}, rows(25, func(i int) map[string]interface{} {
+		record := map[string]interface{}{"name": fmt.Sprint(i), "blob": blob(i)}
+		if i%2 == 1 {
+			record["maybe"] = base64.StdEncoding.EncodeToString(blob(i))
+		}
+		return record
+	})...)
+
+	pr, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderBinary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, se := range pr.FileMetaData().Schema[1:] {
+		if isString := se.Name == "name"; se.IsSetConvertedType() != isString || se.IsSetLogicalType() != isString {
+			t.Fatalf("%s has converted type %v", se.Name, se.ConvertedType)
+		}
+	}
+	for i := 0; i < 25; i++ {
+		var record map[string]interface{}
+		if err := pr.Read(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record["name"] != fmt.Sprint(i) || !bytes.Equal(record["blob"].([]byte), blob(i)) {
+			t.Fatalf("row %d: %v", i, record)
+		}
+		if i%2 == 1 && !bytes.Equal(record["maybe"].([]byte), blob(i)) || i%2 == 0 && record["maybe"] != nil {
+			t.Fatalf("row %d: maybe is %v", i, record["maybe"])
+		}
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderBinary(), park.ParquetReaderFilter(park.Eq("blob", blob(7))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch park.Batch
+	if err := pr.ReadBatch(&batch); err != nil {
+		t.Fatal(err)
+	}
+	c := batch.Column("blob")
+	if batch.Rows != 1 || !c.Binary || !bytes.Equal(c.Value(0).([]byte), blob(7)) {
+		t.Fatalf("read %d rows, %v", batch.Rows, c.Value(0))
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := pr.Read(&record); err != nil {
+		t.Fatal(err)
+	}
+	if record["blob"] != string(blob(0)) {
+		t.Fatalf("blob is %v without ParquetReaderBinary", record["blob"])
+	}
+}
+
+// stripAnnotations rewrites the footer of a file without the converted
+// and logical types of its columns, as older writers wrote them.
+func stripAnnotations(t *testing.T, data []byte) []byte {
+	footer, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, se := range footer.Schema {
+		se.ConvertedType, se.LogicalType = nil, nil
+	}
+	out := &bytes.Buffer{}
+	out.Write(data[:len(data)-8-int(footerSize(data))])
+	if err := park.WriteFooter(out, footer); err != nil {
+		t.Fatal(err)
+	}
+	out.WriteString("PAR1")
+	return out.Bytes()
+}
+
+func Test_unannotatedStrings(t *testing.T) {
+	cols := []park.SchemaColumn{{Name: "name", Type: schema.Type_BYTE_ARRAY}, {Name: "note", Type: schema.Type_BYTE_ARRAY, Optional: true}}
+	annotated := writeColumns(t, cols, layout{}, rows(5, func(i int) map[string]interface{} {
+		record := map[string]interface{}{"name": fmt.Sprint("n", i)}
+		if i%2 == 0 {
+			record["note"] = fmt.Sprint("note ", i)
+		}
+		return record
+	})...)
+	data := stripAnnotations(t, annotated)
+
+	pr, err := park.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if se := pr.FileMetaData().Schema[1]; se.IsSetConvertedType() || se.IsSetLogicalType() {
+		t.Fatal("the footer still has annotations")
+	}
+	for _, f := range pr.Schema().Fields {
+		if f.Binary() {
+			t.Fatalf("column %s without annotation is binary", f.Name())
+		}
+	}
+	for i := 0; i < 5; i++ {
+		var record map[string]interface{}
+		if err := pr.Read(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record["name"] != fmt.Sprint("n", i) || i%2 == 0 && record["note"] != fmt.Sprint("note ", i) {
+			t.Fatalf("record %d is %v", i, record)
+		}
+	}
+
+	merged, err := park.MergeSchemas(bytes.NewReader(data), bytes.NewReader(annotated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Schema.Fields[0].Binary() || merged.Schema.Fields[1].Binary() {
+		t.Fatal("merged unannotated and string columns into bytes")
+	}
+	footer, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := park.NewSchemaFromFileMetaData(footer, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Fields[0].Binary() {
+		t.Fatal("the schema of the footer has a binary column")
+	}
+}
+
+var enumSchema = `{
+  "name": "events",
+  "type": "record",
+  "fields": [
+    {"name": "id", "type": "int"},
+    {"name": "type", "type": {"type": "enum", "name": "kind", "symbols": ["CLICK", "VIEW", "BUY"]}},
+    {"name": "source", "type": ["null", {"type": "enum", "name": "source", "symbols": ["WEB", "APP"], "default": "APP"}]}
+  ]
+}`
+
+func writeEnums(t *testing.T, sc *park.Schema, types ...string) ([]byte, error) {
+	file := &memFile{}
+	pw := park.NewParquetWriter(sc, file, 10)
+	for i, typ := range types {
+		record := map[string]interface{}{"id": int32(i), "type": typ}
+		if i%2 == 0 {
+			record["source"] = "WEB"
+		}
+		if err := pw.Write(&record); err != nil {
+			return nil, err
+		}
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes(), nil
+}
+
+func readEnums(t *testing.T, data []byte) []string {
+	pr, err := park.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for {
+		var record map[string]interface{}
+		err := pr.Read(&record)
+		if err == io.EOF {
+			return types
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source := record["source"]; len(types)%2 == 0 && source != "WEB" || len(types)%2 == 1 && source != nil {
+			t.Fatalf("row %d: source is %v", len(types), source)
+		}
+		types = append(types, record["type"].(string))
+	}
+}
+
+func Test_enum(t *testing.T) {
+	sc, err := park.NewSchema(enumSchema, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := sc.Field("type").Symbols(); len(s) != 3 || s[2] != "BUY" {
+		t.Fatalf("symbols are %v", s)
+	}
+	var types []string
+	for i := 0; i < 25; i++ {
+		types = append(types, sc.Field("type").Symbols()[i%3])
+	}
+	data, err := writeEnums(t, sc, types...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readEnums(t, data); fmt.Sprint(got) != fmt.Sprint(types) {
+		t.Fatalf("read %v", got)
+	}
+	footer, err := park.ReadMetaData(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, se := range footer.Schema[2:] {
+		if se.GetConvertedType() != schema.ConvertedType_ENUM || !se.GetLogicalType().IsSetENUM() {
+			t.Fatalf("%s has converted type %v", se.Name, se.ConvertedType)
+		}
+	}
+	for _, rg := range footer.RowGroups {
+		md := rg.Columns[1].MetaData
+		if md.DictionaryPageOffset == nil || *md.DictionaryPageOffset != rg.Columns[1].FileOffset || md.DataPageOffset <= *md.DictionaryPageOffset {
+			t.Fatalf("dictionary page at %v, data page at %d", md.DictionaryPageOffset, md.DataPageOffset)
+		}
+		if fmt.Sprint(md.Encodings) != "[PLAIN RLE_DICTIONARY]" || md.Statistics == nil || string(md.Statistics.MinValue) != "BUY" {
+			t.Fatalf("encodings %v, statistics %v", md.Encodings, md.Statistics)
+		}
+	}
+
+	if _, err := writeEnums(t, sc, "CLICK", "SCROLL"); err == nil {
+		t.Fatal("expected an error for an unknown symbol")
+	}
+	if err := sc.SetEnumPolicy("type", park.EnumDefault); err != nil {
+		t.Fatal(err)
+	}
+	data, err = writeEnums(t, sc, "CLICK", "SCROLL", "BUY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readEnums(t, data); fmt.Sprint(got) != "[CLICK CLICK BUY]" {
+		t.Fatalf("read %v", got)
+	}
+	if err := sc.SetEnumPolicy("type", park.EnumKeep); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.SetEncoding("type", schema.Encoding_PLAIN); err != nil {
+		t.Fatal(err)
+	}
+	data, err = writeEnums(t, sc, "CLICK", "SCROLL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readEnums(t, data); fmt.Sprint(got) != "[CLICK SCROLL]" {
+		t.Fatalf("read %v", got)
+	}
+	if err := sc.SetEnumPolicy("id", park.EnumKeep); err == nil {
+		t.Fatal("expected an error for a column that is not an enum")
+	}
+}
+
+// intColumns are columns of the signed and unsigned INTEGER annotations,
+// intRecord(i) is their row i.
+var intColumns = []park.SchemaColumn{
+	{Name: "i8", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 8, IsSigned: true}},
+	{Name: "i16", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 16, IsSigned: true}},
+	{Name: "u8", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 8}},
+	{Name: "u16", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 16}},
+	{Name: "u32", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 32}},
+	{Name: "u64", Type: schema.Type_INT64, Int: &schema.IntType{BitWidth: 64}, Optional: true},
+}
+
+func intRecord(i int) map[string]interface{} {
+	return map[string]interface{}{
+		"i8":  int8(i - 10),
+		"i16": int16(-1000 * i),
+		"u8":  uint8(250 + i),
+		"u16": uint16(65000 + 100*i),
+		"u32": uint32(math.MaxUint32 - i),
+		"u64": uint64(math.MaxUint64 - uint64(i)),
+	}
+}
+
+func Test_integers(t *testing.T) {
+	checkAvroColumns(t, `{"fields": [
+    {"name": "i8", "type": {"type": "int", "logicalType": "int8"}},
+    {"name": "i16", "type": {"type": "int", "logicalType": "int16"}},
+    {"name": "u8", "type": {"type": "int", "logicalType": "uint8"}},
+    {"name": "u16", "type": {"type": "int", "logicalType": "uint16"}},
+    {"name": "u32", "type": {"type": "int", "logicalType": "uint32"}},
+    {"name": "u64", "type": ["null", {"type": "long", "logicalType": "uint64"}]}
+  ]}`, intColumns)
+	records := rows(6, intRecord)
+	// JSON numbers and strings are converted, values out of range become
+	// the default
+	records[5] = map[string]interface{}{"i8": float64(-5), "i16": "300", "u8": float64(256), "u16": -1, "u32": "4294967295", "u64": "18446744073709551615"}
+	data := writeColumns(t, intColumns, layout{pageSize: 3}, records...)
+
+	pr, err := park.NewParquetReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	footer := pr.FileMetaData()
+	if len(footer.ColumnOrders) != 6 || !footer.ColumnOrders[0].IsSetTYPE_ORDER() {
+		t.Fatalf("column orders are %v", footer.ColumnOrders)
+	}
+	for _, se := range footer.Schema[1:] {
+		it := se.GetLogicalType().GetINTEGER()
+		if !se.IsSetConvertedType() || it == nil || it.IsSigned != (se.Name[0] == 'i') {
+			t.Fatalf("%s has converted type %v and logical type %v", se.Name, se.ConvertedType, se.LogicalType)
+		}
+	}
+	// the maximum of the first row group is above the signed range
+	u64 := footer.RowGroups[0].Columns[5].MetaData.Statistics
+	if min := park.StatValue(footer.Schema[6], u64.MinValue); min != uint64(math.MaxUint64-2) {
+		t.Fatalf("u64 min is %v", min)
+	}
+	if max := park.StatValue(footer.Schema[6], u64.MaxValue); max != uint64(math.MaxUint64) {
+		t.Fatalf("u64 max is %v", max)
+	}
+	u32 := footer.RowGroups[0].Columns[4].MetaData.Statistics
+	if min := park.StatValue(footer.Schema[5], u32.MinValue); min != uint32(math.MaxUint32-2) {
+		t.Fatalf("u32 min is %v", min)
+	}
+
+	for i := 0; i < 6; i++ {
+		var record map[string]interface{}
+		if err := pr.Read(&record); err != nil {
+			t.Fatal(err)
+		}
+		expected := intRecord(i)
+		if i == 5 {
+			expected = map[string]interface{}{"i8": int8(-5), "i16": int16(300), "u8": uint8(0), "u16": uint16(0), "u32": uint32(math.MaxUint32), "u64": uint64(math.MaxUint64)}
+		}
+		for k, v := range expected {
+			if record[k] != v {
+				t.Fatalf("row %d: %s is %v (%T), expected %v", i, k, record[k], record[k], v)
+			}
+		}
+	}
+
+	pr, err = park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderFilter(park.Gt("u64", uint64(math.MaxUint64-1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for {
+		var record map[string]interface{}
+		if err := pr.Read(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("read %d rows", n)
+	}
+	if _, err := park.NewParquetReader(bytes.NewReader(data), park.ParquetReaderFilter(park.Eq("u8", -1))); err == nil {
+		t.Fatal("expected an error for a negative unsigned literal")
+	}
+
+	sc, err := park.NewSchemaFromFileMetaData(footer, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it := sc.Field("u16").IntType(); it == nil || it.BitWidth != 16 || it.IsSigned {
+		t.Fatalf("u16 is %v", it)
+	}
+}
+
+func Test_writeBatchIntRange(t *testing.T) {
+	sc, err := park.NewSchemaFromColumns([]park.SchemaColumn{
+		{Name: "i8", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 8, IsSigned: true}},
+		{Name: "u16", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 16}, Optional: true},
+		{Name: "u32", Type: schema.Type_INT32, Int: &schema.IntType{BitWidth: 32}},
+	}, schema.CompressionCodec_SNAPPY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := func(i8, u16 int32) *park.Batch {
+		return &park.Batch{Rows: 2, Columns: []park.ColumnBatch{
+			{Name: "i8", Type: schema.Type_INT32, Int32s: []int32{-128, i8}},
+			{Name: "u16", Type: schema.Type_INT32, Int32s: []int32{-1, u16}, Nulls: []bool{true, false}},
+			{Name: "u32", Type: schema.Type_INT32, Int32s: []int32{-1, 7}},
+		}}
+	}
+	pw := park.NewParquetWriter(sc, &memFile{}, 10)
+	if err := pw.WriteBatch(batch(127, 65535)); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []*park.Batch{batch(300, 0), batch(-129, 0), batch(0, -1), batch(0, 65536)} {
+		err := pw.WriteBatch(b)
+		if err == nil || !strings.Contains(err.Error(), "in row 1 is out of the range") {
+			t.Fatalf("%v: %v", b.Columns[:2], err)
+		}
+	}
+}